@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	doconf "docshell/internal/v1/config"
+	"docshell/internal/v1/migrations"
+	"docshell/internal/v1/storage"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `usage:
+  docshell [flags]                         start server
+  docshell [flags] migrate up              apply pending migrations
+  docshell [flags] migrate down [steps]    revert last applied migrations, 1 by default
+  docshell [flags] migrate status          list migrations
+  docshell [flags] config print            show effective configuration, secrets hidden
+
+Every configuration field, e.g. 'service.db.host', is read from config
+file, overridden by environment variable DOCSHELL_SERVICE_DB_HOST, or
+by file named in DOCSHELL_SERVICE_DB_HOST_FILE, overridden by flag
+-service.db.host.
+
+flags:`
+
+// Runs command given in arguments instead of server, configuration
+// is read from configPath with flags overriding it
+func runCommand(configPath string, flags doconf.Flags, args []string) error {
+	switch args[0] {
+	case "migrate":
+		cfg, err := doconf.Load(configPath, flags)
+		if err != nil {
+			return err
+		}
+		return migrate(context.Background(), cfg, args[1:])
+	case "config":
+		cfg, err := doconf.Load(configPath, flags)
+		if err != nil {
+			return err
+		}
+		return config(cfg, args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func config(cfg doconf.Configuration, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New(usage)
+	}
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(doconf.Redacted(cfg)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func migrate(ctx context.Context, cfg doconf.Configuration, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	// Only database is needed
+	con, err := storage.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer con.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(ctx, con)
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || 0 >= n {
+				return fmt.Errorf("steps %q incorrect", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrations.Down(ctx, con, steps)
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("No migrations are applied")
+		}
+		return err
+	case "status":
+		statuses, err := migrations.Status(ctx, con)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+}
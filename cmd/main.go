@@ -2,57 +2,58 @@ package main
 
 import (
 	"context"
+	"docshell/internal/v1/app"
 	doconf "docshell/internal/v1/config"
-	"docshell/internal/v1/docs/handlers"
-	"docshell/internal/v1/middleware/cors"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 func main() {
-	cfg := doconf.Config.Service.Web
-
-	// Opening server
-	doc := chi.NewRouter()
-
-	// Apply middlewares
-	doc.Use(middleware.Logger)
-	doc.Use(cors.CORSMiddleware)
-	doc.Use(middleware.Recoverer)
-
-	// Adding routes
-	doc.Route("/docs", func(r chi.Router) {
-		r.Get("/", handlers.GetAllDocuments)
-		r.Get("/id/{id}", handlers.GetDocumentById)
-
-		r.Post("/", handlers.CreateDocument)
+	// Path of configuration file and its fields may be given with flags
+	flags := flag.NewFlagSet("docshell", flag.ExitOnError)
+	configPath := flags.String("config", "",
+		fmt.Sprintf("path of configuration file, $%s or %s if empty", doconf.PathEnv, doconf.DefaultPath))
+	overrides := doconf.RegisterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+	path := doconf.Path(*configPath)
+
+	// Run command instead of server
+	if flags.NArg() > 0 {
+		if err := runCommand(path, overrides, flags.Args()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-		// With query parameter 'path'
-		r.Get("/download", handlers.DownloadDocument)
-	})
+	cfg, err := doconf.Load(path, overrides)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Start server with goroutine
-	go func() {
-		adr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-		msg := "Server[%s] successfuly started"
-		log.Printf(msg, adr)
-		if err := http.ListenAndServe(adr, doc); err != nil {
-			msg := "Server[%s] fault with %v"
-			log.Fatalf(msg, adr, err)
-		}
-	}()
+	// Open database and storage
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatalf("Service could not be started, because of %v", err)
+	}
 
 	// Graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGABRT)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGABRT)
 	defer stop()
-	// Wait for context to be cancelled
-	<-ctx.Done()
+
+	err = a.Run(ctx)
+	if cerr := a.Close(); cerr != nil {
+		log.Println(cerr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 	log.Println("Server gracefully stopped.")
 }
@@ -3,7 +3,7 @@ module docshell
 go 1.24.0
 
 require (
-	github.com/go-chi/chi/v5 v5.2.2 // indirect
-	github.com/lib/pq v1.10.9 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	github.com/go-chi/chi/v5 v5.2.2
+	github.com/lib/pq v1.10.9
+	gopkg.in/yaml.v3 v3.0.1
 )
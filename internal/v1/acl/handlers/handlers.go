@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"docshell/internal/v1/acl/models"
+	"docshell/internal/v1/acl/service"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Maximum size of request body
+const maxBodySize = 1 << 20
+
+// Handler serves groups of users and grants
+type Handler struct {
+	service *service.Service
+}
+
+func New(service *service.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAllGroups(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ShareFolders) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetAllGroups(ctx, w, r)
+}
+
+func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ShareFolders) {
+		return
+	}
+	// Struct to put in it parsed body
+	var gc models.GroupCreation
+	if !readBody(w, r, &gc) {
+		return
+	}
+	// Call next function and pass context
+	h.service.CreateGroup(ctx, w, r, gc)
+}
+
+func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r, "id")
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ShareFolders) {
+		return
+	}
+	// Call next function and pass context
+	h.service.DeleteGroup(ctx, w, r, id)
+}
+
+func (h *Handler) GetGroupMembers(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r, "id")
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ShareFolders) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetGroupMembers(ctx, w, r, id)
+}
+
+func (h *Handler) AddGroupMember(w http.ResponseWriter, r *http.Request) {
+	// Read path values
+	id, ok := readId(w, r, "id")
+	if !ok {
+		return
+	}
+	userId, ok := readId(w, r, "user_id")
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ShareFolders) {
+		return
+	}
+	// Call next function and pass context
+	h.service.AddGroupMember(ctx, w, r, id, userId)
+}
+
+func (h *Handler) RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	// Read path values
+	id, ok := readId(w, r, "id")
+	if !ok {
+		return
+	}
+	userId, ok := readId(w, r, "user_id")
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ShareFolders) {
+		return
+	}
+	// Call next function and pass context
+	h.service.RemoveGroupMember(ctx, w, r, id, userId)
+}
+
+func (h *Handler) GetGrants(w http.ResponseWriter, r *http.Request) {
+	// Read query params, one of them is required
+	query := r.URL.Query()
+	var documentId *int64
+	var path *string
+	switch {
+	case query.Has("document_id") && !query.Has("path"):
+		id, err := strconv.ParseInt(query.Get("document_id"), 10, 64)
+		if err != nil || 0 >= id {
+			msg := fmt.Sprintf("Query value 'document_id=%v' incorrect", query.Get("document_id"))
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+		documentId = &id
+	case query.Has("path") && !query.Has("document_id"):
+		p := query.Get("path")
+		path = &p
+	default:
+		msg := "Exactly one of query values 'document_id' and 'path' is required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetGrants(ctx, w, r, documentId, path)
+}
+
+func (h *Handler) CreateGrant(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Struct to put in it parsed body
+	var gc models.GrantCreation
+	if !readBody(w, r, &gc) {
+		return
+	}
+	// Call next function and pass context
+	h.service.CreateGrant(ctx, w, r, gc)
+}
+
+func (h *Handler) DeleteGrant(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r, "id")
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.DeleteGrant(ctx, w, r, id)
+}
+
+// Reads positive integer path value, sends error response if incorrect
+func readId(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value '%v=%v' incorrect", name, r.PathValue(name))
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, false
+	}
+	return id, true
+}
+
+// Decodes JSON body into v, sends error response if incorrect
+func readBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	// Read body
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		msg := "Body can not be read"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	if len(body) == 0 { // if empty
+		msg := "Body is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	// Try to decode body into the struct
+	if err := json.Unmarshal(body, v); err != nil {
+		msg := "JSON is incorrect"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	return true
+}
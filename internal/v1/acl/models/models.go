@@ -0,0 +1,64 @@
+package models
+
+// Permissions granted on documents, write includes read
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+// Named set of users, grants to group apply to all its members
+type Group struct {
+	Id        int64  `json:"id" db:"id"`
+	Name      string `json:"name" db:"name"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
+
+type GroupCreation struct {
+	Name string `json:"name"`
+}
+
+// Permission given to user or group on document or folder.
+// Folder grant covers every document under its path.
+type Grant struct {
+	Id         int64   `json:"id" db:"id"`
+	UserId     *int64  `json:"user_id" db:"user_id"`
+	GroupId    *int64  `json:"group_id" db:"group_id"`
+	DocumentId *int64  `json:"document_id" db:"document_id"`
+	Path       *string `json:"path" db:"path"`
+	Permission string  `json:"permission" db:"permission"`
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+}
+
+// Exactly one of UserId and GroupId and
+// exactly one of DocumentId and Path must be set
+type GrantCreation struct {
+	UserId     *int64  `json:"user_id"`
+	GroupId    *int64  `json:"group_id"`
+	DocumentId *int64  `json:"document_id"`
+	Path       *string `json:"path"`
+	Permission string  `json:"permission"`
+}
+
+type ResponseMultipleGroups struct {
+	StatusCode int     `json:"status_code"`
+	Groups     []Group `json:"groups"`
+}
+
+type ResponseSingleGroup struct {
+	StatusCode int   `json:"status_code"`
+	Group      Group `json:"group"`
+}
+
+type ResponseMultipleGrants struct {
+	StatusCode int     `json:"status_code"`
+	Grants     []Grant `json:"grants"`
+}
+
+type ResponseSingleGrant struct {
+	StatusCode int   `json:"status_code"`
+	Grant      Grant `json:"grant"`
+}
+
+type ResponseCode struct {
+	StatusCode int `json:"status_code"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "database/sql"
+
+func ScanGroup(rows *sql.Rows) (Group, error) {
+	group := Group{}
+	if err := rows.Scan(&group.Id, &group.Name, &group.CreatedAt); err != nil {
+		return Group{}, err
+	}
+	return group, nil
+}
+
+func ScanGrant(rows *sql.Rows) (Grant, error) {
+	grant := Grant{}
+	if err := rows.Scan(&grant.Id, &grant.UserId, &grant.GroupId, &grant.DocumentId,
+		&grant.Path, &grant.Permission, &grant.CreatedAt); err != nil {
+		return Grant{}, err
+	}
+	return grant, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/acl/models"
+	"docshell/internal/v1/storage"
+)
+
+func GetGrantById(ctx context.Context, con *sql.DB, id int64) (models.Grant, error) {
+	return queryGrant(ctx, con, get_grant_by_id, id)
+}
+
+// GetDocumentGrants returns grants given on document itself
+func GetDocumentGrants(ctx context.Context, con *sql.DB, documentId int64) ([]models.Grant, error) {
+	return queryGrants(ctx, con, get_document_grants, documentId)
+}
+
+// GetFolderGrants returns grants given on folder path
+func GetFolderGrants(ctx context.Context, con *sql.DB, path string) ([]models.Grant, error) {
+	return queryGrants(ctx, con, get_folder_grants, path)
+}
+
+func CreateGrant(ctx context.Context, con *sql.DB, gc models.GrantCreation) (models.Grant, error) {
+	return queryGrant(ctx, con, insert_grant,
+		gc.UserId, gc.GroupId, gc.DocumentId, gc.Path, gc.Permission)
+}
+
+// DeleteGrant removes grant and returns it
+func DeleteGrant(ctx context.Context, con *sql.DB, id int64) (models.Grant, error) {
+	return queryGrant(ctx, con, delete_grant, id)
+}
+
+// Runs query returning single grant
+func queryGrant(ctx context.Context, con *sql.DB, query string, args ...any) (models.Grant, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.Grant{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanGrant)
+}
+
+// Runs query returning many grants
+func queryGrants(ctx context.Context, con *sql.DB, query string, args ...any) ([]models.Grant, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanGrant)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/acl/models"
+	"docshell/internal/v1/storage"
+)
+
+func GetAllGroups(ctx context.Context, con *sql.DB) ([]models.Group, error) {
+	rows, err := con.QueryContext(ctx, get_all_groups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanGroup)
+}
+
+func GetGroupById(ctx context.Context, con *sql.DB, id int64) (models.Group, error) {
+	return queryGroup(ctx, con, get_group_by_id, id)
+}
+
+func CreateGroup(ctx context.Context, con *sql.DB, name string) (models.Group, error) {
+	return queryGroup(ctx, con, insert_group, name)
+}
+
+// DeleteGroup removes group with its members and grants and returns it
+func DeleteGroup(ctx context.Context, con *sql.DB, id int64) (models.Group, error) {
+	return queryGroup(ctx, con, delete_group, id)
+}
+
+// GetGroupMemberIds returns ids of users in group
+func GetGroupMemberIds(ctx context.Context, con *sql.DB, groupId int64) ([]int64, error) {
+	rows, err := con.QueryContext(ctx, get_group_member_ids, groupId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, func(rows *sql.Rows) (int64, error) {
+		var id int64
+		err := rows.Scan(&id)
+		return id, err
+	})
+}
+
+// AddGroupMember adds user to group, adding existing member does nothing
+func AddGroupMember(ctx context.Context, con *sql.DB, groupId, userId int64) error {
+	_, err := con.ExecContext(ctx, insert_group_member, groupId, userId)
+	return err
+}
+
+// RemoveGroupMember removes user from group and reports whether it was a member
+func RemoveGroupMember(ctx context.Context, con *sql.DB, groupId, userId int64) (bool, error) {
+	res, err := con.ExecContext(ctx, delete_group_member, groupId, userId)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Runs query returning single group
+func queryGroup(ctx context.Context, con *sql.DB, query string, args ...any) (models.Group, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.Group{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanGroup)
+}
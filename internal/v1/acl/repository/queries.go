@@ -0,0 +1,33 @@
+package repository
+
+const (
+	// Groups
+	group_columns = "id, name, created_at"
+
+	get_all_groups  = "select " + group_columns + " from groups order by id;"
+	get_group_by_id = "select " + group_columns + " from groups where id = $1;"
+	insert_group    = "insert into groups (name) values ($1) returning " + group_columns + ";"
+	delete_group    = "delete from groups where id = $1 returning " + group_columns + ";"
+
+	// Members
+	get_group_member_ids = "select user_id from group_members where group_id = $1 order by user_id;"
+	insert_group_member  = `
+		insert into group_members (group_id, user_id)
+			values ($1, $2)
+			on conflict do nothing;
+	`
+	delete_group_member = "delete from group_members where group_id = $1 and user_id = $2;"
+
+	// Grants
+	grant_columns = "id, user_id, group_id, document_id, path, permission, created_at"
+
+	get_grant_by_id     = "select " + grant_columns + " from grants where id = $1;"
+	get_document_grants = "select " + grant_columns + " from grants where document_id = $1 order by id;"
+	get_folder_grants   = "select " + grant_columns + " from grants where path = $1 order by id;"
+	insert_grant        = `
+		insert into grants (user_id, group_id, document_id, path, permission)
+			values ($1, $2, $3, $4, $5)
+			returning ` + grant_columns + `;
+	`
+	delete_grant = "delete from grants where id = $1 returning " + grant_columns + ";"
+)
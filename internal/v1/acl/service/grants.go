@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/acl/models"
+	"docshell/internal/v1/acl/repository"
+	docRepository "docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/storage"
+	"docshell/internal/v1/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Service manages groups of users and grants given to them
+type Service struct {
+	// Database of groups and grants
+	db *sql.DB
+	// Document records, grants are given on documents
+	documents docRepository.DocumentRepository
+}
+
+func New(db *sql.DB, documents docRepository.DocumentRepository) *Service {
+	return &Service{db: db, documents: documents}
+}
+
+// GetGrants sends grants given on document or on folder path
+func (s *Service) GetGrants(ctx context.Context, w http.ResponseWriter, r *http.Request, documentId *int64, path *string) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Only users able to share may read grants
+	if !s.authorizeSharing(ctx, w, documentId) {
+		return
+	}
+
+	var grants []models.Grant
+	var err error
+	if documentId != nil {
+		grants, err = repository.GetDocumentGrants(ctx, con, *documentId)
+	} else {
+		grants, err = repository.GetFolderGrants(ctx, con, utils.CleanPath(*path))
+	}
+	if err != nil {
+		msg := "Database error: could not read grants"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleGrants{
+		StatusCode: http.StatusOK,
+		Grants:     make([]models.Grant, len(grants)),
+	}
+	copy(res.Grants, grants)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) CreateGrant(ctx context.Context, w http.ResponseWriter, r *http.Request, gc models.GrantCreation) {
+	// Validate fields
+	if (gc.UserId == nil) == (gc.GroupId == nil) {
+		msg := "Exactly one of 'user_id' and 'group_id' is required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if (gc.DocumentId == nil) == (gc.Path == nil) {
+		msg := "Exactly one of 'document_id' and 'path' is required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if gc.Permission != models.PermissionRead && gc.Permission != models.PermissionWrite {
+		msg := fmt.Sprintf("Permission '%v' incorrect, expected 'read' or 'write'", gc.Permission)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if gc.Path != nil {
+		p := utils.CleanPath(*gc.Path)
+		gc.Path = &p
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check user may share document or folder
+	if !s.authorizeSharing(ctx, w, gc.DocumentId) {
+		return
+	}
+
+	// Save grant
+	grant, err := repository.CreateGrant(ctx, con, gc)
+	if err != nil {
+		// Grantee already has permission on target
+		if storage.IsUniqueViolation(err) {
+			msg := "Grant for such user or group already exists"
+			utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+			return
+		}
+		if storage.IsForeignKeyViolation(err) {
+			msg := "User, group or document not found"
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+		log.Println(err)
+		msg := "Database error: could not save grant"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleGrant{
+		StatusCode: http.StatusOK,
+		Grant:      grant,
+	})
+}
+
+func (s *Service) DeleteGrant(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Get grant to check who may delete it
+	grant, err := repository.GetGrantById(ctx, con, id)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if grant == (models.Grant{}) {
+		msg := "Requested grant not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+	if !s.authorizeSharing(ctx, w, grant.DocumentId) {
+		return
+	}
+
+	// Delete grant
+	grant, err = repository.DeleteGrant(ctx, con, id)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not delete grant"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if grant == (models.Grant{}) {
+		msg := "Requested grant not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleGrant{
+		StatusCode: http.StatusOK,
+		Grant:      grant,
+	})
+}
+
+// Checks user may share document, or folder if documentId is nil.
+// Documents are shared by users who may write them,
+// folders only by users whose role allows it.
+func (s *Service) authorizeSharing(ctx context.Context, w http.ResponseWriter, documentId *int64) bool {
+	if documentId == nil {
+		return policy.Authorize(ctx, w, policy.ShareFolders)
+	}
+	return policy.AuthorizeDocument(ctx, w, s.documents, int(*documentId), models.PermissionWrite)
+}
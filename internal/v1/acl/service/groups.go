@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"docshell/internal/v1/acl/models"
+	"docshell/internal/v1/acl/repository"
+	"docshell/internal/v1/storage"
+	usersModels "docshell/internal/v1/users/models"
+	usersRepository "docshell/internal/v1/users/repository"
+	"docshell/internal/v1/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func (s *Service) GetAllGroups(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Get all groups
+	groups, err := repository.GetAllGroups(ctx, con)
+	if err != nil {
+		msg := "Database error: could not read groups"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleGroups{
+		StatusCode: http.StatusOK,
+		Groups:     make([]models.Group, len(groups)),
+	}
+	copy(res.Groups, groups)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) CreateGroup(ctx context.Context, w http.ResponseWriter, r *http.Request, gc models.GroupCreation) {
+	// Validate fields
+	gc.Name = strings.TrimSpace(gc.Name)
+	if gc.Name == "" {
+		msg := "Name is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Save group
+	group, err := repository.CreateGroup(ctx, con, gc.Name)
+	if err != nil {
+		if storage.IsUniqueViolation(err) {
+			msg := "Group with such name already exists"
+			utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+			return
+		}
+		log.Println(err)
+		msg := "Database error: could not save group"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleGroup{
+		StatusCode: http.StatusOK,
+		Group:      group,
+	})
+}
+
+func (s *Service) DeleteGroup(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Delete group, its members and grants go with it
+	group, err := repository.DeleteGroup(ctx, con, id)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not delete group"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if group == (models.Group{}) {
+		msg := "Requested group not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleGroup{
+		StatusCode: http.StatusOK,
+		Group:      group,
+	})
+}
+
+func (s *Service) GetGroupMembers(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check group exists
+	if !s.groupExists(ctx, w, id) {
+		return
+	}
+
+	// Get members
+	ids, err := repository.GetGroupMemberIds(ctx, con, id)
+	if err != nil {
+		msg := "Database error: could not read members"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	users, err := usersRepository.GetUsersByIds(ctx, con, ids)
+	if err != nil {
+		msg := "Database error: could not read users"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := usersModels.ResponseMultipleUsers{
+		StatusCode: http.StatusOK,
+		Users:      make([]usersModels.User, len(users)),
+	}
+	copy(res.Users, users)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) AddGroupMember(ctx context.Context, w http.ResponseWriter, r *http.Request, id, userId int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check group exists
+	if !s.groupExists(ctx, w, id) {
+		return
+	}
+
+	// Add member
+	if err := repository.AddGroupMember(ctx, con, id, userId); err != nil {
+		if storage.IsForeignKeyViolation(err) {
+			msg := "Requested user not found"
+			utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+			return
+		}
+		log.Println(err)
+		msg := "Database error: could not add member"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseCode{
+		StatusCode: http.StatusOK,
+	})
+}
+
+func (s *Service) RemoveGroupMember(ctx context.Context, w http.ResponseWriter, r *http.Request, id, userId int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Remove member
+	removed, err := repository.RemoveGroupMember(ctx, con, id, userId)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not remove member"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if !removed {
+		msg := "User is not a member of requested group"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseCode{
+		StatusCode: http.StatusOK,
+	})
+}
+
+// Checks group exists, otherwise sends error response
+func (s *Service) groupExists(ctx context.Context, w http.ResponseWriter, id int64) bool {
+	group, err := repository.GetGroupById(ctx, s.db, id)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return false
+	}
+	if group == (models.Group{}) {
+		msg := "Requested group not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return false
+	}
+	return true
+}
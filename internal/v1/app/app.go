@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	aclService "docshell/internal/v1/acl/service"
+	"docshell/internal/v1/auth"
+	authService "docshell/internal/v1/auth/service"
+	doconf "docshell/internal/v1/config"
+	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/docs/service"
+	foldersService "docshell/internal/v1/folders/service"
+	"docshell/internal/v1/migrations"
+	"docshell/internal/v1/storage"
+	usersService "docshell/internal/v1/users/service"
+	"docshell/internal/v1/volume"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// How long requests in progress may take on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// App is the service with its dependencies, every service
+// and handler is given what it uses by New
+type App struct {
+	Config doconf.Configuration
+	// Postgres database of documents, users, grants, shares and search
+	DB *sql.DB
+	// Files of documents
+	Storage volume.Storage
+	// Document records kept in DB
+	Documents repository.DocumentRepository
+	// Serves every endpoint
+	Handler http.Handler
+
+	auth    *authService.Service
+	users   *usersService.Service
+	docs    *service.Service
+	folders *foldersService.Service
+	acl     *aclService.Service
+}
+
+// New opens database and storage described in cfg and builds handlers
+// using them. Nothing is left open on failure, otherwise App must be
+// closed with Close.
+func New(cfg doconf.Configuration) (*App, error) {
+	a := &App{Config: cfg}
+
+	// Key signing access tokens and pre-signed URLs
+	signer, err := auth.NewSigner(cfg.Auth.Secret,
+		time.Duration(cfg.Auth.AccessTTL)*time.Minute,
+		time.Duration(cfg.Auth.RefreshTTL)*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open database
+	db, err := storage.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	a.DB = db
+
+	// Open storage of files
+	a.Storage, err = volume.Open(cfg)
+	if err != nil {
+		a.Close()
+		return nil, err
+	}
+
+	// Document records are kept with everything referring to them
+	a.Documents = repository.NewPostgres(db)
+
+	// Build services
+	retention := time.Duration(cfg.Trash.Retention) * time.Hour
+	a.auth = authService.New(db, signer)
+	a.users = usersService.New(db)
+	a.docs = service.New(db, a.Storage, a.Documents, signer, retention)
+	a.folders = foldersService.New(db, a.Documents)
+	a.acl = aclService.New(db, a.Documents)
+
+	a.Handler = a.newRouter(signer)
+	return a, nil
+}
+
+// Run prepares database and serves requests until ctx is done,
+// requests in progress are finished then
+func (a *App) Run(ctx context.Context) error {
+	// Bring schema up to date
+	if a.Config.Service.DB.Migrate {
+		if _, err := migrations.Up(ctx, a.DB); err != nil {
+			return fmt.Errorf("migrations could not be applied: %w", err)
+		}
+	}
+
+	// Seed first admin into empty database
+	if err := a.users.EnsureAdmin(ctx, a.Config.Auth.Admin.Name,
+		a.Config.Auth.Admin.Email, a.Config.Auth.Admin.Password); err != nil {
+		return fmt.Errorf("admin could not be created: %w", err)
+	}
+
+	// Create folders of documents saved before folders existed
+	if err := a.folders.EnsureDocumentFolders(ctx); err != nil {
+		return fmt.Errorf("folders could not be created: %w", err)
+	}
+
+	// Move files of documents saved before blob store into it
+	if err := a.docs.MoveLegacyFiles(ctx); err != nil {
+		return fmt.Errorf("files could not be moved into blob store: %w", err)
+	}
+
+	// Purge trash and unreferenced blobs in background
+	go a.docs.RunCleanup(ctx)
+	// Extract text of uploaded content for search in background
+	go a.docs.RunIndexer(ctx)
+	// Remove stale sessions in background
+	go a.auth.RunCleanup(ctx)
+
+	// Start server with goroutine
+	cfg := a.Config.Service.Web
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Handler: a.Handler,
+	}
+	fault := make(chan error, 1)
+	go func() {
+		log.Printf("Server[%s] successfuly started", server.Addr)
+		fault <- server.ListenAndServe()
+	}()
+
+	// Wait for context to be cancelled
+	select {
+	case err := <-fault:
+		return fmt.Errorf("server[%s] fault with %w", server.Addr, err)
+	case <-ctx.Done():
+	}
+
+	// Finish requests in progress
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+// Close releases storage and database
+func (a *App) Close() error {
+	var errs []error
+	if a.Storage != nil {
+		errs = append(errs, a.Storage.Close())
+	}
+	if a.DB != nil {
+		errs = append(errs, a.DB.Close())
+	}
+	return errors.Join(errs...)
+}
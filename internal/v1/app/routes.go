@@ -0,0 +1,167 @@
+package app
+
+import (
+	aclHandlers "docshell/internal/v1/acl/handlers"
+	"docshell/internal/v1/auth"
+	authHandlers "docshell/internal/v1/auth/handlers"
+	"docshell/internal/v1/docs/handlers"
+	foldersHandlers "docshell/internal/v1/folders/handlers"
+	"docshell/internal/v1/middleware/authn"
+	"docshell/internal/v1/middleware/cors"
+	"docshell/internal/v1/middleware/presign"
+	usersHandlers "docshell/internal/v1/users/handlers"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Builds router serving every endpoint with handlers of app's services
+func (a *App) newRouter(signer *auth.Signer) http.Handler {
+	authHandler := authHandlers.New(a.auth)
+	usersHandler := usersHandlers.New(a.users)
+	docsHandler := handlers.New(a.docs, a.Storage, a.Config.Service.Web.MaxUploadSize<<20)
+	foldersHandler := foldersHandlers.New(a.folders)
+	aclHandler := aclHandlers.New(a.acl)
+
+	doc := chi.NewRouter()
+
+	// Apply middlewares
+	doc.Use(middleware.Logger)
+	doc.Use(cors.CORSMiddleware)
+	doc.Use(middleware.Recoverer)
+
+	// Adding routes
+	doc.Route("/auth", func(r chi.Router) {
+		r.Post("/login", authHandler.Login)
+		r.Post("/refresh", authHandler.Refresh)
+
+		// Require access token or API key
+		r.Group(func(r chi.Router) {
+			r.Use(authn.AuthMiddleware(a.auth))
+			r.Post("/logout", authHandler.Logout)
+			r.Get("/me", authHandler.Me)
+
+			// Credentials are managed by users or admin API keys
+			r.Group(func(r chi.Router) {
+				r.Use(authn.ScopeMiddleware(auth.ScopeAdmin, auth.ScopeAdmin))
+				r.Get("/sessions", authHandler.GetSessions)
+				r.Delete("/sessions/{id}", authHandler.RevokeSession)
+
+				r.Get("/keys", authHandler.GetApiKeys)
+				r.Post("/keys", authHandler.CreateApiKey)
+				r.Delete("/keys/{id}", authHandler.RevokeApiKey)
+			})
+		})
+	})
+
+	doc.Route("/docs", func(r chi.Router) {
+		r.Use(authn.AuthMiddleware(a.auth))
+		r.Use(authn.ScopeMiddleware(auth.ScopeDocsRead, auth.ScopeDocsWrite))
+
+		r.Get("/", docsHandler.GetAllDocuments)
+		// With query parameter 'q'
+		r.Get("/search", docsHandler.SearchDocuments)
+		r.Get("/id/{id}", docsHandler.GetDocumentById)
+		r.Get("/id/{id}/content", docsHandler.DownloadDocumentById)
+
+		r.Post("/", docsHandler.CreateDocument)
+
+		// Pre-signed URLs for access without token
+		r.Get("/id/{id}/presign", docsHandler.PresignDownload)
+		r.Post("/presign", docsHandler.PresignUpload)
+		r.Put("/id/{id}", docsHandler.UpdateDocument)
+		r.Patch("/id/{id}", docsHandler.UpdateDocument)
+		r.Delete("/id/{id}", docsHandler.DeleteDocument)
+
+		// Change place of document or copy it
+		r.Post("/id/{id}/move", docsHandler.MoveDocument)
+		r.Post("/id/{id}/rename", docsHandler.RenameDocument)
+		r.Post("/id/{id}/copy", docsHandler.CopyDocument)
+
+		// Previous versions of document
+		r.Get("/id/{id}/versions", docsHandler.GetVersions)
+		r.Get("/id/{id}/versions/{version}/content", docsHandler.DownloadVersion)
+		r.Post("/id/{id}/versions/{version}/revert", docsHandler.RevertDocument)
+
+		// Public links to document
+		r.Get("/id/{id}/shares", docsHandler.GetShares)
+		r.Post("/id/{id}/shares", docsHandler.CreateShare)
+		r.Delete("/id/{id}/shares/{share_id}", docsHandler.RevokeShare)
+		r.Get("/id/{id}/shares/{share_id}/downloads", docsHandler.GetShareDownloads)
+
+		// Deleted documents
+		r.Get("/trash", docsHandler.GetTrash)
+		r.Post("/trash/{id}/restore", docsHandler.RestoreDocument)
+
+		// With query parameter 'path', deprecated in favour of '/id/{id}/content'
+		r.Get("/download", docsHandler.DownloadDocument)
+	})
+
+	// Folders with documents in them
+	doc.Route("/folders", func(r chi.Router) {
+		r.Use(authn.AuthMiddleware(a.auth))
+		r.Use(authn.ScopeMiddleware(auth.ScopeDocsRead, auth.ScopeDocsWrite))
+
+		r.Get("/", foldersHandler.GetRootFolder)
+		r.Get("/id/{id}", foldersHandler.GetFolder)
+
+		r.Post("/", foldersHandler.CreateFolder)
+		// Renames and moves folder
+		r.Patch("/id/{id}", foldersHandler.UpdateFolder)
+		r.Delete("/id/{id}", foldersHandler.DeleteFolder)
+	})
+
+	doc.Route("/users", func(r chi.Router) {
+		// Require access token or admin API key
+		r.Use(authn.AuthMiddleware(a.auth))
+		r.Use(authn.ScopeMiddleware(auth.ScopeAdmin, auth.ScopeAdmin))
+
+		r.Get("/", usersHandler.GetAllUsers)
+		r.Get("/id/{id}", usersHandler.GetUserById)
+
+		r.Post("/", usersHandler.CreateUser)
+		r.Put("/id/{id}", usersHandler.UpdateUser)
+		r.Patch("/id/{id}", usersHandler.UpdateUser)
+		r.Delete("/id/{id}", usersHandler.DeleteUser)
+	})
+
+	// Pre-signed requests, signature authorizes request
+	doc.Route("/presigned", func(r chi.Router) {
+		r.Use(presign.PresignMiddleware(signer, a.DB))
+
+		r.Get("/docs/id/{id}/content", docsHandler.DownloadDocumentById)
+		r.Put("/docs/upload", docsHandler.UploadPresigned)
+	})
+
+	// Public share links, token authorizes request
+	doc.Get("/s/{token}", docsHandler.DownloadShare)
+	doc.Head("/s/{token}", docsHandler.DownloadShare)
+
+	// Groups of users grants can be given to
+	doc.Route("/groups", func(r chi.Router) {
+		r.Use(authn.AuthMiddleware(a.auth))
+		r.Use(authn.ScopeMiddleware(auth.ScopeAdmin, auth.ScopeAdmin))
+
+		r.Get("/", aclHandler.GetAllGroups)
+		r.Post("/", aclHandler.CreateGroup)
+		r.Delete("/id/{id}", aclHandler.DeleteGroup)
+
+		r.Get("/id/{id}/members", aclHandler.GetGroupMembers)
+		r.Put("/id/{id}/members/{user_id}", aclHandler.AddGroupMember)
+		r.Delete("/id/{id}/members/{user_id}", aclHandler.RemoveGroupMember)
+	})
+
+	// Permissions on documents and folders
+	doc.Route("/grants", func(r chi.Router) {
+		r.Use(authn.AuthMiddleware(a.auth))
+		r.Use(authn.ScopeMiddleware(auth.ScopeDocsWrite, auth.ScopeDocsWrite))
+
+		// With query parameter 'document_id' or 'path'
+		r.Get("/", aclHandler.GetGrants)
+		r.Post("/", aclHandler.CreateGrant)
+		r.Delete("/id/{id}", aclHandler.DeleteGrant)
+	})
+
+	return doc
+}
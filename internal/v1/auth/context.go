@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"docshell/internal/v1/users/models"
+)
+
+// Keys of values put into request context
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	sessionKey
+	scopesKey
+)
+
+// WithUser returns context carrying authenticated user and its session
+func WithUser(ctx context.Context, user models.User, sessionId int64) context.Context {
+	ctx = context.WithValue(ctx, userKey, user)
+	return context.WithValue(ctx, sessionKey, sessionId)
+}
+
+// UserFromContext returns authenticated user put by auth middleware
+func UserFromContext(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(userKey).(models.User)
+	return user, ok
+}
+
+// SessionFromContext returns id of session user is authenticated with
+func SessionFromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(sessionKey).(int64)
+	return id, ok
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/auth/models"
+	"docshell/internal/v1/auth/service"
+	"docshell/internal/v1/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Maximum size of request body
+const maxBodySize = 1 << 20
+
+// Handler serves sign in, sessions and API keys
+type Handler struct {
+	service *service.Service
+}
+
+func New(service *service.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	// Struct to put in it parsed body
+	var creds models.Credentials
+	if !readBody(w, r, &creds) {
+		return
+	}
+	if creds.Email == "" || creds.Password == "" {
+		msg := "Fields 'email' and 'password' are required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.Login(ctx, w, r, creds)
+}
+
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	// Struct to put in it parsed body
+	var rr models.RefreshRequest
+	if !readBody(w, r, &rr) {
+		return
+	}
+	if rr.RefreshToken == "" {
+		msg := "Field 'refresh_token' is required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.Refresh(ctx, w, r, rr)
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.Logout(ctx, w, r)
+}
+
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.Me(ctx, w, r)
+}
+
+func (h *Handler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.GetSessions(ctx, w, r)
+}
+
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Users can revoke only their own sessions
+	user, _ := auth.UserFromContext(ctx)
+	// Call next function and pass context
+	h.service.RevokeSession(ctx, w, r, user.Id, id)
+}
+
+// Reads 'id' path value, sends error response if incorrect
+func readId(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", r.PathValue("id"))
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, false
+	}
+	return id, true
+}
+
+// Decodes JSON body into v, sends error response if incorrect
+func readBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	// Read body
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		msg := "Body can not be read"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	if len(body) == 0 { // if empty
+		msg := "Body is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	// Try to decode body into the struct
+	if err := json.Unmarshal(body, v); err != nil {
+		msg := "JSON is incorrect"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) GetApiKeys(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.GetApiKeys(ctx, w, r)
+}
+
+func (h *Handler) CreateApiKey(w http.ResponseWriter, r *http.Request) {
+	// Struct to put in it parsed body
+	var kc models.ApiKeyCreation
+	if !readBody(w, r, &kc) {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.CreateApiKey(ctx, w, r, kc)
+}
+
+func (h *Handler) RevokeApiKey(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Users can revoke only their own keys
+	user, _ := auth.UserFromContext(ctx)
+	// Call next function and pass context
+	h.service.RevokeApiKey(ctx, w, r, user.Id, id)
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	usersModels "docshell/internal/v1/users/models"
+	"time"
+)
+
+// Login session, refresh token is stored only as hash
+type Session struct {
+	Id        int64  `json:"id" db:"id"`
+	UserId    int64  `json:"user_id" db:"user_id"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+	ExpiresAt string `json:"expires_at" db:"expires_at"`
+	// Set when session is logged out or revoked
+	RevokedAt *string `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ResponseToken struct {
+	StatusCode  int    `json:"status_code"`
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// Lifetime of access token in seconds
+	ExpiresIn    int64            `json:"expires_in"`
+	RefreshToken string           `json:"refresh_token"`
+	User         usersModels.User `json:"user"`
+}
+
+type ResponseMultipleSessions struct {
+	StatusCode int       `json:"status_code"`
+	Sessions   []Session `json:"sessions"`
+}
+
+type ResponseSingleSession struct {
+	StatusCode int     `json:"status_code"`
+	Session    Session `json:"session"`
+}
+
+// Key for access without user session, key itself is stored only as hash
+type ApiKey struct {
+	Id     int64  `json:"id" db:"id"`
+	UserId int64  `json:"user_id" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+	// Leading characters of key to recognize it
+	Prefix     string   `json:"prefix" db:"prefix"`
+	Scopes     []string `json:"scopes" db:"scopes"`
+	ExpiresAt  *string  `json:"expires_at" db:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  string   `json:"created_at" db:"created_at"`
+	// Set when key is revoked
+	RevokedAt *string `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+type ApiKeyCreation struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Key never expires if nil
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// API key together with its owner
+type ApiKeyOwner struct {
+	Key  ApiKey
+	User usersModels.User
+}
+
+type ResponseMultipleApiKeys struct {
+	StatusCode int      `json:"status_code"`
+	ApiKeys    []ApiKey `json:"api_keys"`
+}
+
+type ResponseSingleApiKey struct {
+	StatusCode int    `json:"status_code"`
+	ApiKey     ApiKey `json:"api_key"`
+}
+
+// Sent once on creation, key can not be read later
+type ResponseCreatedApiKey struct {
+	StatusCode int    `json:"status_code"`
+	ApiKey     ApiKey `json:"api_key"`
+	Key        string `json:"key"`
+}
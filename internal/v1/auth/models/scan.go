@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+func ScanSession(rows *sql.Rows) (Session, error) {
+	session := Session{}
+	if err := rows.Scan(&session.Id, &session.UserId, &session.CreatedAt,
+		&session.ExpiresAt, &session.RevokedAt); err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func ScanApiKey(rows *sql.Rows) (ApiKey, error) {
+	key := ApiKey{}
+	if err := rows.Scan(&key.Id, &key.UserId, &key.Name, &key.Prefix,
+		pq.Array(&key.Scopes), &key.ExpiresAt, &key.LastUsedAt,
+		&key.CreatedAt, &key.RevokedAt); err != nil {
+		return ApiKey{}, err
+	}
+	return key, nil
+}
+
+// ScanApiKeyOwner scans API key columns followed by user columns
+func ScanApiKeyOwner(rows *sql.Rows) (ApiKeyOwner, error) {
+	o := ApiKeyOwner{}
+	if err := rows.Scan(&o.Key.Id, &o.Key.UserId, &o.Key.Name, &o.Key.Prefix,
+		pq.Array(&o.Key.Scopes), &o.Key.ExpiresAt, &o.Key.LastUsedAt,
+		&o.Key.CreatedAt, &o.Key.RevokedAt,
+		&o.User.Id, &o.User.Name, &o.User.Email,
+		&o.User.PasswordHash, &o.User.Role, &o.User.CreatedAt, &o.User.ChangedAt); err != nil {
+		return ApiKeyOwner{}, err
+	}
+	return o, nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Query parameters added to pre-signed URLs
+const (
+	presignUser      = "user"
+	presignExpires   = "expires"
+	presignSignature = "signature"
+)
+
+var (
+	// Returned when pre-signed URL is not signed or was altered
+	ErrInvalidSignature = errors.New("invalid signature")
+	// Returned when pre-signed URL is used after its expiry
+	ErrExpiredSignature = errors.New("signature expired")
+)
+
+// PresignURL signs request of user with method to path and query,
+// URL is valid until expires. Returned URL has no scheme and host.
+func (s *Signer) PresignURL(method, path string, query url.Values, userId int64, expires time.Time) string {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set(presignUser, strconv.FormatInt(userId, 10))
+	q.Set(presignExpires, strconv.FormatInt(expires.Unix(), 10))
+	q.Set(presignSignature, s.signRequest(method, path, q))
+	return path + "?" + q.Encode()
+}
+
+// VerifyPresigned checks signature and expiry of pre-signed
+// request and returns id of user who signed it
+func (s *Signer) VerifyPresigned(method string, u *url.URL, now time.Time) (int64, error) {
+	q := u.Query()
+	signature := q.Get(presignSignature)
+	if signature == "" {
+		return 0, ErrInvalidSignature
+	}
+	// Compare signatures in constant time
+	if !hmac.Equal([]byte(signature), []byte(s.signRequest(method, u.Path, q))) {
+		return 0, ErrInvalidSignature
+	}
+
+	expires, err := strconv.ParseInt(q.Get(presignExpires), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidSignature
+	}
+	if now.Unix() >= expires {
+		return 0, ErrExpiredSignature
+	}
+	userId, err := strconv.ParseInt(q.Get(presignUser), 10, 64)
+	if err != nil || userId <= 0 {
+		return 0, ErrInvalidSignature
+	}
+	return userId, nil
+}
+
+// Signs method, path and query except signature itself,
+// query is encoded with sorted keys so order does not matter
+func (s *Signer) signRequest(method, path string, query url.Values) string {
+	q := url.Values{}
+	for k, v := range query {
+		if k != presignSignature {
+			q[k] = v
+		}
+	}
+
+	// Key differs from one of access tokens,
+	// so token signature can not be used as URL signature
+	key := hmac.New(sha256.New, s.secret)
+	key.Write([]byte("presign"))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(method + "\n" + path + "\n" + q.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
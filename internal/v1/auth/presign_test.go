@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyPresigned(t *testing.T) {
+	s, err := NewSigner("test secret", 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	expires := now.Add(5 * time.Minute)
+
+	download := s.PresignURL(http.MethodGet, "/presigned/docs/id/7/content", nil, 42, expires)
+	upload := s.PresignURL(http.MethodPut, "/presigned/docs/upload", url.Values{
+		"author_id": {"42"},
+		"path":      {"reports/2024"},
+		"title":     {"q1.pdf"},
+		"nonce":     {"n0nce"},
+	}, 42, expires)
+
+	// Returns URL with query value of key changed
+	withQuery := func(rawURL, key, value string) string {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := u.Query()
+		q.Set(key, value)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	// Returns URL without query value of key
+	withoutQuery := func(rawURL, key string) string {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := u.Query()
+		q.Del(key)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	other, err := NewSigner("other secret", 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		now    time.Time
+		err    error
+	}{
+		{name: "download", method: http.MethodGet, url: download, now: now},
+		{name: "upload", method: http.MethodPut, url: upload, now: now},
+		{name: "second before expiry", method: http.MethodGet, url: download, now: expires.Add(-time.Second)},
+		{name: "at expiry", method: http.MethodGet, url: download, now: expires, err: ErrExpiredSignature},
+		{name: "after expiry", method: http.MethodGet, url: download, now: expires.Add(time.Hour), err: ErrExpiredSignature},
+		{name: "no signature", method: http.MethodGet, url: withoutQuery(download, presignSignature), now: now,
+			err: ErrInvalidSignature},
+		{name: "empty signature", method: http.MethodGet, url: withQuery(download, presignSignature, ""), now: now,
+			err: ErrInvalidSignature},
+		{name: "signed with other key", method: http.MethodGet,
+			url: other.PresignURL(http.MethodGet, "/presigned/docs/id/7/content", nil, 42, expires), now: now,
+			err: ErrInvalidSignature},
+		{name: "wrong method", method: http.MethodHead, url: download, now: now, err: ErrInvalidSignature},
+		{name: "download replayed as PUT", method: http.MethodPut, url: download, now: now, err: ErrInvalidSignature},
+		{name: "upload replayed as GET", method: http.MethodGet, url: upload, now: now, err: ErrInvalidSignature},
+		{name: "tampered path", method: http.MethodGet,
+			url: strings.Replace(download, "/id/7/", "/id/8/", 1), now: now, err: ErrInvalidSignature},
+		{name: "download signature on upload path", method: http.MethodPut,
+			url: strings.Replace(download, "/presigned/docs/id/7/content", "/presigned/docs/upload", 1), now: now,
+			err: ErrInvalidSignature},
+		{name: "tampered user", method: http.MethodGet, url: withQuery(download, presignUser, "1"), now: now,
+			err: ErrInvalidSignature},
+		{name: "extended expiry", method: http.MethodGet,
+			url: withQuery(download, presignExpires, "1800000000"), now: expires, err: ErrInvalidSignature},
+		{name: "tampered upload folder", method: http.MethodPut, url: withQuery(upload, "path", "admin"), now: now,
+			err: ErrInvalidSignature},
+		{name: "tampered upload nonce", method: http.MethodPut, url: withQuery(upload, "nonce", "other"), now: now,
+			err: ErrInvalidSignature},
+		{name: "added query value", method: http.MethodGet, url: withQuery(download, "disposition", "inline"), now: now,
+			err: ErrInvalidSignature},
+		{name: "removed query value", method: http.MethodPut, url: withoutQuery(upload, "author_id"), now: now,
+			err: ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			userId, err := s.VerifyPresigned(tt.method, u, tt.now)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("VerifyPresigned() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyPresigned() error = %v", err)
+			}
+			if userId != 42 {
+				t.Fatalf("VerifyPresigned() = %d, want 42", userId)
+			}
+		})
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	s, err := NewSigner("test secret", 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := url.Values{"user": {"42"}, "expires": {"1700000300"}}
+	signature := s.signRequest(http.MethodGet, "/presigned/docs/id/7/content", query)
+
+	// Order of query keys and present signature do not change it
+	reordered := url.Values{"expires": {"1700000300"}, "user": {"42"}, presignSignature: {"anything"}}
+	if got := s.signRequest(http.MethodGet, "/presigned/docs/id/7/content", reordered); got != signature {
+		t.Errorf("signRequest() = %s, want %s", got, signature)
+	}
+
+	// Method, path and query are each covered
+	changed := []struct {
+		name   string
+		method string
+		path   string
+		query  url.Values
+	}{
+		{name: "method", method: http.MethodPut, path: "/presigned/docs/id/7/content", query: query},
+		{name: "path", method: http.MethodGet, path: "/presigned/docs/id/70/content", query: query},
+		{name: "query", method: http.MethodGet, path: "/presigned/docs/id/7/content",
+			query: url.Values{"user": {"42"}, "expires": {"1700000301"}}},
+	}
+	for _, tt := range changed {
+		if got := s.signRequest(tt.method, tt.path, tt.query); got == signature {
+			t.Errorf("signRequest() with changed %s = %s, same as original", tt.name, got)
+		}
+	}
+
+	// URL signature differs from access token signature with the same key
+	if s.signRequest(http.MethodGet, "/", nil) == s.sign(http.MethodGet+"\n/\n") {
+		t.Error("signRequest() equals token signature of the same message")
+	}
+}
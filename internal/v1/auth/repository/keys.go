@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/auth/models"
+	"docshell/internal/v1/storage"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CreateApiKey saves hashed key of user, key without expiresAt never expires
+func CreateApiKey(ctx context.Context, con *sql.DB, userId int64, name, prefix, keyHash string,
+	scopes []string, expiresAt *time.Time) (models.ApiKey, error) {
+	return queryApiKey(ctx, con, insert_api_key, userId, name, prefix, keyHash, pq.Array(scopes), expiresAt)
+}
+
+// GetUserApiKeys returns not revoked keys of user
+func GetUserApiKeys(ctx context.Context, con *sql.DB, userId int64) ([]models.ApiKey, error) {
+	rows, err := con.QueryContext(ctx, get_user_api_keys, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanApiKey)
+}
+
+// UseApiKey returns usable key with given hash and its owner,
+// updating time of last use. Empty result means key is unknown,
+// expired or revoked.
+func UseApiKey(ctx context.Context, con *sql.DB, keyHash string) (models.ApiKeyOwner, error) {
+	rows, err := con.QueryContext(ctx, use_api_key, keyHash)
+	if err != nil {
+		return models.ApiKeyOwner{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanApiKeyOwner)
+}
+
+// RevokeApiKey revokes key of user
+func RevokeApiKey(ctx context.Context, con *sql.DB, id, userId int64) (models.ApiKey, error) {
+	return queryApiKey(ctx, con, revoke_api_key, id, userId)
+}
+
+// Runs query returning single API key
+func queryApiKey(ctx context.Context, con *sql.DB, query string, args ...any) (models.ApiKey, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.ApiKey{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanApiKey)
+}
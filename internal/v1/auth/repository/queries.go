@@ -0,0 +1,77 @@
+package repository
+
+const (
+	session_columns = "id, user_id, created_at, expires_at, revoked_at"
+
+	insert_session = `
+		insert into sessions (user_id, refresh_hash, expires_at)
+			values ($1, $2, $3)
+			returning ` + session_columns + `;
+	`
+	// User of active session
+	get_session_user = `
+		select u.id, u.name, u.email, u.password_hash, u.role, u.created_at, u.changed_at
+			from sessions s
+			join users u on u.id = s.user_id
+			where s.id = $1 and s.revoked_at is null and s.expires_at > now();
+	`
+	get_user_sessions = `
+		select ` + session_columns + ` from sessions
+			where user_id = $1 and revoked_at is null and expires_at > now()
+			order by id;
+	`
+	// Replaces refresh token of active session, old token can not be used again
+	rotate_session = `
+		update sessions
+			set refresh_hash = $2, expires_at = $3
+			where refresh_hash = $1 and revoked_at is null and expires_at > now()
+			returning ` + session_columns + `;
+	`
+	revoke_session = `
+		update sessions
+			set revoked_at = now()
+			where id = $1 and user_id = $2 and revoked_at is null
+			returning ` + session_columns + `;
+	`
+	revoke_user_sessions = `
+		update sessions
+			set revoked_at = now()
+			where user_id = $1 and revoked_at is null;
+	`
+	// Sessions which can not be used anymore
+	delete_stale_sessions = `
+		delete from sessions
+			where expires_at <= now() or revoked_at is not null;
+	`
+
+	api_key_columns = "id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at, revoked_at"
+
+	insert_api_key = `
+		insert into api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+			values ($1, $2, $3, $4, $5, $6)
+			returning ` + api_key_columns + `;
+	`
+	get_user_api_keys = `
+		select ` + api_key_columns + ` from api_keys
+			where user_id = $1 and revoked_at is null
+			order by id;
+	`
+	// Marks usable key as used and returns it with its owner
+	use_api_key = `
+		update api_keys k
+			set last_used_at = now()
+			from users u
+			where k.key_hash = $1 and u.id = k.user_id
+				and k.revoked_at is null
+				and (k.expires_at is null or k.expires_at > now())
+			returning k.id, k.user_id, k.name, k.prefix, k.scopes,
+				k.expires_at, k.last_used_at, k.created_at, k.revoked_at,
+				u.id, u.name, u.email, u.password_hash, u.role, u.created_at, u.changed_at;
+	`
+	revoke_api_key = `
+		update api_keys
+			set revoked_at = now()
+			where id = $1 and user_id = $2 and revoked_at is null
+			returning ` + api_key_columns + `;
+	`
+)
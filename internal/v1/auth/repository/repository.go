@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/auth/models"
+	"docshell/internal/v1/storage"
+	usersModels "docshell/internal/v1/users/models"
+	"time"
+)
+
+// CreateSession starts session of user with hashed refresh token
+func CreateSession(ctx context.Context, con *sql.DB, userId int64, refreshHash string, expiresAt time.Time) (models.Session, error) {
+	return querySession(ctx, con, insert_session, userId, refreshHash, expiresAt)
+}
+
+// GetSessionUser returns user of session if it is neither expired nor revoked
+func GetSessionUser(ctx context.Context, con *sql.DB, sessionId int64) (usersModels.User, error) {
+	rows, err := con.QueryContext(ctx, get_session_user, sessionId)
+	if err != nil {
+		return usersModels.User{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, usersModels.ScanUser)
+}
+
+// GetUserSessions returns active sessions of user
+func GetUserSessions(ctx context.Context, con *sql.DB, userId int64) ([]models.Session, error) {
+	rows, err := con.QueryContext(ctx, get_user_sessions, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanSession)
+}
+
+// RotateSession replaces refresh token of active session,
+// empty session is returned if old token is unknown
+func RotateSession(ctx context.Context, con *sql.DB, oldHash, newHash string, expiresAt time.Time) (models.Session, error) {
+	return querySession(ctx, con, rotate_session, oldHash, newHash, expiresAt)
+}
+
+// RevokeSession ends session of user
+func RevokeSession(ctx context.Context, con *sql.DB, sessionId, userId int64) (models.Session, error) {
+	return querySession(ctx, con, revoke_session, sessionId, userId)
+}
+
+// RevokeUserSessions ends all sessions of user
+func RevokeUserSessions(ctx context.Context, con *sql.DB, userId int64) error {
+	_, err := con.ExecContext(ctx, revoke_user_sessions, userId)
+	return err
+}
+
+// DeleteStaleSessions removes expired and revoked sessions
+func DeleteStaleSessions(ctx context.Context, con *sql.DB) (int64, error) {
+	res, err := con.ExecContext(ctx, delete_stale_sessions)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Runs query returning single session
+func querySession(ctx context.Context, con *sql.DB, query string, args ...any) (models.Session, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.Session{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanSession)
+}
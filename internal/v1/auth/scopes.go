@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Scopes which can be granted to API keys
+const (
+	ScopeDocsRead  = "docs:read"
+	ScopeDocsWrite = "docs:write"
+	// Grants every other scope
+	ScopeAdmin = "admin"
+)
+
+// Prefix telling API keys apart from access tokens
+const ApiKeyPrefix = "dsk_"
+
+// Scopes lists all known scopes
+var Scopes = []string{ScopeDocsRead, ScopeDocsWrite, ScopeAdmin}
+
+// IsScope reports whether scope is known
+func IsScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// WithScopes returns context limited to scopes of API key
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// HasScope reports whether request may act within scope.
+// Requests authenticated by session are not limited.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(scopesKey).([]string)
+	if !ok {
+		return true
+	}
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
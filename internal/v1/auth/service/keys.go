@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/auth/models"
+	"docshell/internal/v1/auth/repository"
+	usersModels "docshell/internal/v1/users/models"
+	"docshell/internal/v1/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Length of key start kept to recognize it
+const keyPrefixLength = len(auth.ApiKeyPrefix) + 8
+
+// Returned when API key is unknown, expired or revoked
+var ErrInvalidApiKey = errors.New("invalid API key")
+
+func (s *Service) GetApiKeys(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Get keys of current user
+	user, _ := auth.UserFromContext(ctx)
+	keys, err := repository.GetUserApiKeys(ctx, con, user.Id)
+	if err != nil {
+		msg := "Database error: could not read API keys"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleApiKeys{
+		StatusCode: http.StatusOK,
+		ApiKeys:    make([]models.ApiKey, len(keys)),
+	}
+	copy(res.ApiKeys, keys)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) CreateApiKey(ctx context.Context, w http.ResponseWriter, r *http.Request, kc models.ApiKeyCreation) {
+	// Validate fields
+	kc.Name = strings.TrimSpace(kc.Name)
+	if kc.Name == "" {
+		msg := "Name is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if len(kc.Scopes) == 0 {
+		msg := fmt.Sprintf("At least one scope of %v is required", auth.Scopes)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	for _, scope := range kc.Scopes {
+		if !auth.IsScope(scope) {
+			msg := fmt.Sprintf("Scope '%v' incorrect", scope)
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+	slices.Sort(kc.Scopes)
+	kc.Scopes = slices.Compact(kc.Scopes)
+	if kc.ExpiresAt != nil && !kc.ExpiresAt.After(time.Now()) {
+		msg := "Expiry time is in the past"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Generate key
+	key, err := auth.NewApiKey()
+	if err != nil {
+		log.Println(err)
+		msg := "API key could not be issued"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Save key of current user
+	user, _ := auth.UserFromContext(ctx)
+	apiKey, err := repository.CreateApiKey(ctx, con, user.Id, kc.Name,
+		key[:keyPrefixLength], auth.HashToken(key), kc.Scopes, kc.ExpiresAt)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not save API key"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Key is shown only once
+	w.Header().Set("Cache-Control", "no-store")
+	utils.SendJSONResponse(w, models.ResponseCreatedApiKey{
+		StatusCode: http.StatusOK,
+		ApiKey:     apiKey,
+		Key:        key,
+	})
+}
+
+// RevokeApiKey revokes one of user's keys
+func (s *Service) RevokeApiKey(ctx context.Context, w http.ResponseWriter, r *http.Request, userId, id int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Revoke key
+	apiKey, err := repository.RevokeApiKey(ctx, con, id, userId)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not revoke API key"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if apiKey.Id == 0 {
+		msg := "Requested API key not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleApiKey{
+		StatusCode: http.StatusOK,
+		ApiKey:     apiKey,
+	})
+}
+
+// AuthenticateApiKey checks API key and returns its owner and scopes
+func (s *Service) AuthenticateApiKey(ctx context.Context, key string) (usersModels.User, []string, error) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Find usable key
+	owner, err := repository.UseApiKey(ctx, con, auth.HashToken(key))
+	if err != nil {
+		return usersModels.User{}, nil, err
+	}
+	if owner.Key.Id == 0 {
+		return usersModels.User{}, nil, ErrInvalidApiKey
+	}
+	return owner.User, owner.Key.Scopes, nil
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/auth/models"
+	"docshell/internal/v1/auth/repository"
+	util "docshell/internal/v1/users"
+	usersModels "docshell/internal/v1/users/models"
+	usersRepository "docshell/internal/v1/users/repository"
+	"docshell/internal/v1/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How often stale sessions are removed
+const cleanupInterval = time.Hour
+
+// Returned when session of access token is expired or revoked
+var ErrSessionEnded = errors.New("session expired or revoked")
+
+// Hash checked when user is not found, so response time does not
+// tell whether email is registered. It is made on first login.
+var dummyHash = sync.OnceValues(func() (string, error) {
+	return util.HashPassword("dummy password")
+})
+
+// Service signs users in and keeps their sessions and API keys
+type Service struct {
+	// Database of users, sessions and API keys
+	db *sql.DB
+	// Issues access tokens
+	signer *auth.Signer
+}
+
+func New(db *sql.DB, signer *auth.Signer) *Service {
+	return &Service{db: db, signer: signer}
+}
+
+func (s *Service) Login(ctx context.Context, w http.ResponseWriter, r *http.Request, creds models.Credentials) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Find user by email
+	email := strings.ToLower(strings.TrimSpace(creds.Email))
+	user, err := usersRepository.GetUserByEmail(ctx, con, email)
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Check password
+	hash := user.PasswordHash
+	if user == (usersModels.User{}) {
+		hash, err = dummyHash()
+		if err != nil {
+			log.Println(err)
+			msg := "Internal server error"
+			utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+			return
+		}
+	}
+	if !util.CheckPassword(creds.Password, hash) || user == (usersModels.User{}) {
+		msg := "Email or password incorrect"
+		utils.SendJSONErrorResponse(w, http.StatusUnauthorized, msg)
+		return
+	}
+
+	// Start session
+	refresh, err := auth.NewRefreshToken()
+	if err != nil {
+		log.Println(err)
+		msg := "Token could not be issued"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	now := time.Now()
+	session, err := repository.CreateSession(ctx, con, user.Id,
+		auth.HashToken(refresh), now.Add(s.signer.RefreshTTL()))
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not save session"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	s.sendTokens(w, user, session, refresh, now)
+}
+
+func (s *Service) Refresh(ctx context.Context, w http.ResponseWriter, r *http.Request, rr models.RefreshRequest) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Replace refresh token, each one can be used only once
+	refresh, err := auth.NewRefreshToken()
+	if err != nil {
+		log.Println(err)
+		msg := "Token could not be issued"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	now := time.Now()
+	session, err := repository.RotateSession(ctx, con, auth.HashToken(rr.RefreshToken),
+		auth.HashToken(refresh), now.Add(s.signer.RefreshTTL()))
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not save session"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if session == (models.Session{}) {
+		msg := "Refresh token incorrect, expired or revoked"
+		utils.SendJSONErrorResponse(w, http.StatusUnauthorized, msg)
+		return
+	}
+
+	// Get session user
+	user, err := repository.GetSessionUser(ctx, con, session.Id)
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if user == (usersModels.User{}) {
+		msg := "Refresh token incorrect, expired or revoked"
+		utils.SendJSONErrorResponse(w, http.StatusUnauthorized, msg)
+		return
+	}
+
+	s.sendTokens(w, user, session, refresh, now)
+}
+
+// Logout revokes session of request's access token
+func (s *Service) Logout(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(ctx)
+	sessionId, _ := auth.SessionFromContext(ctx)
+	// API keys have no session, they are revoked by id
+	if sessionId == 0 {
+		msg := "Request is not authenticated by session"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	s.RevokeSession(ctx, w, r, user.Id, sessionId)
+}
+
+func (s *Service) GetSessions(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Get active sessions of current user
+	user, _ := auth.UserFromContext(ctx)
+	sessions, err := repository.GetUserSessions(ctx, con, user.Id)
+	if err != nil {
+		msg := "Database error: could not read sessions"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleSessions{
+		StatusCode: http.StatusOK,
+		Sessions:   make([]models.Session, len(sessions)),
+	}
+	copy(res.Sessions, sessions)
+	utils.SendJSONResponse(w, res)
+}
+
+// RevokeSession ends one of user's sessions,
+// access tokens issued for it are rejected from now on
+func (s *Service) RevokeSession(ctx context.Context, w http.ResponseWriter, r *http.Request, userId, sessionId int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Revoke session
+	session, err := repository.RevokeSession(ctx, con, sessionId, userId)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not revoke session"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if session == (models.Session{}) {
+		msg := "Requested session not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleSession{
+		StatusCode: http.StatusOK,
+		Session:    session,
+	})
+}
+
+// Authenticate checks access token and returns its user and session id
+func (s *Service) Authenticate(ctx context.Context, token string) (usersModels.User, int64, error) {
+	claims, err := s.signer.ParseAccessToken(token, time.Now())
+	if err != nil {
+		return usersModels.User{}, 0, err
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Token is valid only while its session is active
+	user, err := repository.GetSessionUser(ctx, con, claims.SessionId)
+	if err != nil {
+		return usersModels.User{}, 0, err
+	}
+	if user.Id != claims.UserId() {
+		return usersModels.User{}, 0, ErrSessionEnded
+	}
+	return user, claims.SessionId, nil
+}
+
+// RunCleanup removes expired and revoked sessions
+// periodically until ctx is done
+func (s *Service) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := repository.DeleteStaleSessions(ctx, s.db); err != nil {
+			log.Printf("Session cleanup fault with %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sends new token pair of session
+func (s *Service) sendTokens(w http.ResponseWriter, user usersModels.User, session models.Session, refresh string, now time.Time) {
+	access, err := s.signer.NewAccessToken(user.Id, session.Id, now)
+	if err != nil {
+		log.Println(err)
+		msg := "Token could not be issued"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Tokens must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+	utils.SendJSONResponse(w, models.ResponseToken{
+		StatusCode:   http.StatusOK,
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.signer.AccessTTL() / time.Second),
+		RefreshToken: refresh,
+		User:         user,
+	})
+}
+
+// Me sends authenticated user
+func (s *Service) Me(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(ctx)
+	utils.SendJSONResponse(w, usersModels.ResponseSingleUser{
+		StatusCode: http.StatusOK,
+		User:       user,
+	})
+}
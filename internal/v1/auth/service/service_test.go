@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/auth/models"
+	"docshell/internal/v1/migrations"
+	util "docshell/internal/v1/users"
+	usersModels "docshell/internal/v1/users/models"
+	usersRepository "docshell/internal/v1/users/repository"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// Connection string of Postgres database tests may migrate and write into
+const databaseEnv = "DOCSHELL_TEST_DATABASE_URL"
+
+// Opens migrated test database, test is skipped if it is not configured
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv(databaseEnv)
+	if url == "" {
+		t.Skipf("%s is not set", databaseEnv)
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrations.Up(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// Runs call and decodes issued tokens, zero value is returned on failure
+func issueTokens(t *testing.T, call func(w http.ResponseWriter, r *http.Request)) (int, models.ResponseToken) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	call(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	var res models.ResponseToken
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, res
+}
+
+func TestRefreshRotation(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	// User signing in
+	hash, err := util.HashPassword("rotation password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := fmt.Sprintf("rotation-%d@example.com", time.Now().UnixNano())
+	user, err := usersRepository.CreateUser(ctx, db, "rotation", email, hash, usersModels.RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { usersRepository.DeleteUser(context.Background(), db, user.Id) })
+
+	signer, err := auth.NewSigner("test secret", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(db, signer)
+	refresh := func(token string) (int, models.ResponseToken) {
+		return issueTokens(t, func(w http.ResponseWriter, r *http.Request) {
+			s.Refresh(ctx, w, r, models.RefreshRequest{RefreshToken: token})
+		})
+	}
+
+	code, login := issueTokens(t, func(w http.ResponseWriter, r *http.Request) {
+		s.Login(ctx, w, r, models.Credentials{Email: email, Password: "rotation password"})
+	})
+	if code != http.StatusOK {
+		t.Fatalf("Login() status = %d, want %d", code, http.StatusOK)
+	}
+
+	// Refresh token is replaced on use
+	code, rotated := refresh(login.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("Refresh() status = %d, want %d", code, http.StatusOK)
+	}
+	if rotated.RefreshToken == login.RefreshToken {
+		t.Fatal("Refresh() returned the same refresh token")
+	}
+	if rotated.User.Id != user.Id {
+		t.Fatalf("Refresh() user = %d, want %d", rotated.User.Id, user.Id)
+	}
+
+	// Rotated token can not be used again
+	if code, _ := refresh(login.RefreshToken); code != http.StatusUnauthorized {
+		t.Fatalf("Refresh() with rotated token status = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	// Its replacement keeps the session
+	code, again := refresh(rotated.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("Refresh() with new token status = %d, want %d", code, http.StatusOK)
+	}
+	claims, err := signer.ParseAccessToken(again.AccessToken, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := signer.ParseAccessToken(login.AccessToken, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.SessionId != first.SessionId {
+		t.Fatalf("Refresh() session = %d, want %d", claims.SessionId, first.SessionId)
+	}
+}
+
+func TestDummyHash(t *testing.T) {
+	hash, err := dummyHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := dummyHash(); again != hash {
+		t.Fatal("dummyHash() made another hash")
+	}
+	if util.CheckPassword("rotation password", hash) {
+		t.Fatal("dummy hash accepted other password")
+	}
+}
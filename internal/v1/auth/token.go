@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Returned when access token is malformed, forged or expired
+var ErrInvalidToken = errors.New("invalid access token")
+
+// Encoded header of every issued token, tokens
+// with other header are rejected to pin the algorithm
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Signer issues and checks access tokens and pre-signed URLs
+type Signer struct {
+	// Key signing tokens and URLs
+	secret []byte
+	// Lifetime of access tokens
+	accessTTL time.Duration
+	// Lifetime of refresh tokens
+	refreshTTL time.Duration
+}
+
+// Claims carried by access token
+type Claims struct {
+	// Id of authenticated user
+	Subject string `json:"sub"`
+	// Id of session token was issued for
+	SessionId int64 `json:"sid"`
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// UserId returns id of authenticated user
+func (c Claims) UserId() int64 {
+	id, _ := strconv.ParseInt(c.Subject, 10, 64)
+	return id
+}
+
+// NewSigner returns signer using key, random
+// one is generated if it is empty
+func NewSigner(key string, accessTTL, refreshTTL time.Duration) (*Signer, error) {
+	s := &Signer{secret: []byte(key), accessTTL: accessTTL, refreshTTL: refreshTTL}
+	if key != "" {
+		return s, nil
+	}
+	s.secret = make([]byte, 32)
+	if _, err := rand.Read(s.secret); err != nil {
+		return nil, fmt.Errorf("unable to generate token secret: %w", err)
+	}
+	log.Println("Auth secret is not set, issued tokens are lost on exit")
+	return s, nil
+}
+
+// AccessTTL returns lifetime of access tokens
+func (s *Signer) AccessTTL() time.Duration {
+	return s.accessTTL
+}
+
+// RefreshTTL returns lifetime of refresh tokens
+func (s *Signer) RefreshTTL() time.Duration {
+	return s.refreshTTL
+}
+
+// NewAccessToken issues HS256 signed JWT for user's session
+func (s *Signer) NewAccessToken(userId, sessionId int64, now time.Time) (string, error) {
+	claims, err := json.Marshal(Claims{
+		Subject:   strconv.FormatInt(userId, 10),
+		SessionId: sessionId,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + s.sign(unsigned), nil
+}
+
+// ParseAccessToken checks signature and expiry of token and returns its claims
+func (s *Signer) ParseAccessToken(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return Claims{}, ErrInvalidToken
+	}
+	// Compare signatures in constant time
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[0]+"."+parts[1]))) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if claims.UserId() <= 0 || now.Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// NewRefreshToken returns random opaque token,
+// only its hash is stored
+func NewRefreshToken() (string, error) {
+	return randomToken()
+}
+
+// NewApiKey returns random API key, only its hash is stored
+func NewApiKey() (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	return ApiKeyPrefix + token, nil
+}
+
+// NewShareToken returns random token of public share link,
+// only its hash is stored
+func NewShareToken() (string, error) {
+	return randomToken()
+}
+
+// NewNonce returns random value making signed URL unique
+func NewNonce() (string, error) {
+	return randomToken()
+}
+
+// IsApiKey reports whether bearer token is an API key
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, ApiKeyPrefix)
+}
+
+// HashToken returns hash under which refresh token or API key is stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Returns 256 random bits encoded for use in headers
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Signs token with HMAC-SHA256
+func (s *Signer) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
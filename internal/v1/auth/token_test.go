@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Signs claims payload under header with key of signer
+func signedToken(s *Signer, header, payload string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return unsigned + "." + s.sign(unsigned)
+}
+
+func TestParseAccessToken(t *testing.T) {
+	s, err := NewSigner("test secret", 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	token, err := s.NewAccessToken(42, 7, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := now.Add(15 * time.Minute)
+
+	// Flips lowest bit of first signature character
+	parts := strings.Split(token, ".")
+	signature := []byte(parts[2])
+	signature[0] ^= 1
+	tampered := parts[0] + "." + parts[1] + "." + string(signature)
+
+	other, err := NewSigner("other secret", 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := other.NewAccessToken(42, 7, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := `{"sub":"42","sid":7,"iat":1700000000,"exp":1700000900}`
+	tests := []struct {
+		name  string
+		token string
+		now   time.Time
+		valid bool
+	}{
+		{name: "valid", token: token, now: now, valid: true},
+		{name: "second before expiry", token: token, now: expiresAt.Add(-time.Second), valid: true},
+		{name: "at expiry", token: token, now: expiresAt},
+		{name: "after expiry", token: token, now: expiresAt.Add(time.Second)},
+		{name: "one bit of signature flipped", token: tampered, now: now},
+		{name: "signed with other key", token: forged, now: now},
+		{name: "no signature", token: parts[0] + "." + parts[1] + ".", now: now},
+		{name: "missing part", token: parts[0] + "." + parts[1], now: now},
+		{name: "alg none", token: signedToken(s, `{"alg":"none","typ":"JWT"}`, claims), now: now},
+		{name: "alg none unsigned", token: base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) +
+			"." + parts[1] + ".", now: now},
+		{name: "alg HS512", token: signedToken(s, `{"alg":"HS512","typ":"JWT"}`, claims), now: now},
+		{name: "header reordered", token: signedToken(s, `{"typ":"JWT","alg":"HS256"}`, claims), now: now},
+		{name: "signed payload", token: signedToken(s, `{"alg":"HS256","typ":"JWT"}`, claims), now: now, valid: true},
+		{name: "non-numeric sub", token: signedToken(s, `{"alg":"HS256","typ":"JWT"}`,
+			`{"sub":"admin","sid":7,"iat":1700000000,"exp":1700000900}`), now: now},
+		{name: "zero sub", token: signedToken(s, `{"alg":"HS256","typ":"JWT"}`,
+			`{"sub":"0","sid":7,"iat":1700000000,"exp":1700000900}`), now: now},
+		{name: "negative sub", token: signedToken(s, `{"alg":"HS256","typ":"JWT"}`,
+			`{"sub":"-42","sid":7,"iat":1700000000,"exp":1700000900}`), now: now},
+		{name: "numeric sub", token: signedToken(s, `{"alg":"HS256","typ":"JWT"}`,
+			`{"sub":42,"sid":7,"iat":1700000000,"exp":1700000900}`), now: now},
+		{name: "payload not JSON", token: signedToken(s, `{"alg":"HS256","typ":"JWT"}`, "42"), now: now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ParseAccessToken(tt.token, tt.now)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("ParseAccessToken() error = %v, want %v", err, ErrInvalidToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAccessToken() error = %v", err)
+			}
+			if got.UserId() != 42 || got.SessionId != 7 || got.ExpiresAt != expiresAt.Unix() {
+				t.Fatalf("ParseAccessToken() = %+v, want user 42 of session 7", got)
+			}
+		})
+	}
+}
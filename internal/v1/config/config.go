@@ -1,37 +1,77 @@
 package doconf
 
 import (
-	"log"
+	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
 )
 
-var Config Configuration
-
-// ? get config path from CLI or PathVar ?
-const CONFIG_PATH = "config.yaml"
+const (
+	// Path of configuration file used when none is given
+	DefaultPath = "config.yaml"
+	// Environment variable with path of configuration file
+	PathEnv = "DOCSHELL_CONFIG"
+)
 
 type Configuration struct {
 	Version string `yaml:"version"`
 
 	Volume string `yaml:"volume"`
 
+	Storage struct {
+		// One of 'local', 'memory' or 's3'
+		Backend string `yaml:"backend"`
+
+		S3 struct {
+			Endpoint  string `yaml:"endpoint"`
+			Region    string `yaml:"region"`
+			Bucket    string `yaml:"bucket"`
+			AccessKey string `yaml:"access_key" secret:"true"`
+			SecretKey string `yaml:"secret_key" secret:"true"`
+		} `yaml:"s3"`
+	} `yaml:"storage"`
+
+	Trash struct {
+		// Hours to keep deleted documents before purge
+		Retention int `yaml:"retention"`
+	} `yaml:"trash"`
+
+	Auth struct {
+		// Key signing access tokens, random one is used if empty
+		Secret string `yaml:"secret" secret:"true"`
+		// Lifetime of access tokens in minutes
+		AccessTTL int `yaml:"access_ttl"`
+		// Lifetime of refresh tokens in hours
+		RefreshTTL int `yaml:"refresh_ttl"`
+
+		// Created on start when there are no users
+		Admin struct {
+			Name     string `yaml:"name"`
+			Email    string `yaml:"email"`
+			Password string `yaml:"password" secret:"true"`
+		} `yaml:"admin"`
+	} `yaml:"auth"`
+
 	Service struct {
 		Web struct {
 			Host string `yaml:"host"`
 			Port int    `yaml:"port"`
+			// Maximum size of uploaded file in megabytes
+			MaxUploadSize int64 `yaml:"max_upload_size"`
 		} `yaml:"web"`
 
 		DB struct {
 			Host    string `yaml:"host"`
 			Port    int    `yaml:"port"`
 			SSLMode string `yaml:"sslmode"`
+			// Apply pending migrations on start
+			Migrate bool `yaml:"migrate"`
 
 			Environment struct {
 				PostgresDB       string `yaml:"POSTGRES_DB"`
 				PostgresUser     string `yaml:"POSTGRES_USER"`
-				PostgresPassword string `yaml:"POSTGRES_PASSWORD"`
+				PostgresPassword string `yaml:"POSTGRES_PASSWORD" secret:"true"`
 			} `yaml:"environment"`
 
 			Settings struct {
@@ -44,18 +84,57 @@ type Configuration struct {
 	}
 }
 
-func init() {
+// Path returns path of configuration file, flag value is preferred
+// over environment variable and default path is used if neither is set
+func Path(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	if env := os.Getenv(PathEnv); env != "" {
+		return env
+	}
+	return DefaultPath
+}
+
+// Load reads configuration from YAML file at path. Fields are
+// overridden by environment variables and those by flags.
+func Load(path string, flags Flags) (Configuration, error) {
+	var cfg Configuration
+
 	// Read YAML file
-	file, err := os.ReadFile(CONFIG_PATH)
+	file, err := os.ReadFile(path)
 	if err != nil {
-		msg := "Could not read file %v"
-		log.Fatalf(msg, CONFIG_PATH)
+		return cfg, fmt.Errorf("could not read file %v: %w", path, err)
 	}
 
 	// Parse YAML into struct
-	err = yaml.Unmarshal(file, &Config)
-	if err != nil {
-		msg := "Could not parse %v file"
-		log.Fatalf(msg, CONFIG_PATH)
+	if err := yaml.Unmarshal(file, &cfg); err != nil {
+		return cfg, fmt.Errorf("could not parse %v file: %w", path, err)
+	}
+
+	// Apply overrides
+	for _, f := range fields(&cfg) {
+		if err := applyEnv(f); err != nil {
+			return cfg, err
+		}
+		if value, ok := flags[f.key]; ok {
+			if err := f.set(value); err != nil {
+				return cfg, err
+			}
+		}
+	}
+
+	if err := validate(cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Rejects values which would make service misbehave silently
+func validate(cfg Configuration) error {
+	// Zero retention would purge trash right after deletion
+	if cfg.Trash.Retention <= 0 {
+		return fmt.Errorf("trash.retention must be positive number of hours, got %d", cfg.Trash.Retention)
 	}
+	return nil
 }
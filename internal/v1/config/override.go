@@ -0,0 +1,134 @@
+package doconf
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Prefix of environment variables overriding configuration fields,
+	// e.g. DOCSHELL_SERVICE_DB_HOST overrides 'service.db.host'
+	EnvPrefix = "DOCSHELL_"
+	// Suffix of environment variables naming file with the value
+	fileSuffix = "_FILE"
+	// Shown instead of secrets
+	redacted = "[redacted]"
+)
+
+// Flags are configuration values given on command line by field key
+type Flags map[string]string
+
+// Configuration field with its key, e.g. 'service.db.host'
+type field struct {
+	key    string
+	secret bool
+	value  reflect.Value
+}
+
+// Lists fields of cfg, they are set through returned values
+func fields(cfg *Configuration) []field {
+	var list []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			// Key is made of YAML names, field name is used without one
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "" {
+				name = f.Name
+			}
+			key := prefix + strings.ToLower(name)
+
+			if f.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			list = append(list, field{key: key, secret: f.Tag.Get("secret") == "true", value: v.Field(i)})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return list
+}
+
+// Parses s into field
+func (f field) set(s string) error {
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("value %q of %s incorrect", s, f.key)
+		}
+		f.value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("value %q of %s incorrect", s, f.key)
+		}
+		f.value.SetInt(n)
+	default:
+		return fmt.Errorf("%s can not be overridden", f.key)
+	}
+	return nil
+}
+
+// EnvName returns environment variable overriding field with key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// RegisterFlags adds flag overriding every configuration field to fs,
+// e.g. '-service.db.host'. Values of given flags are put into result.
+func RegisterFlags(fs *flag.FlagSet) Flags {
+	values := Flags{}
+	// Values are checked on parse against fields of scratch configuration
+	var scratch Configuration
+	for _, f := range fields(&scratch) {
+		fs.Func(f.key, fmt.Sprintf("overrides %s, also $%s", f.key, EnvName(f.key)), func(s string) error {
+			if err := f.set(s); err != nil {
+				return err
+			}
+			values[f.key] = s
+			return nil
+		})
+	}
+	return values
+}
+
+// Applies environment variable of field, value is read
+// from file named by variable with '_FILE' suffix too
+func applyEnv(f field) error {
+	name := EnvName(f.key)
+	value, ok := os.LookupEnv(name)
+	path, fromFile := os.LookupEnv(name + fileSuffix)
+	switch {
+	case ok && fromFile:
+		return fmt.Errorf("both %s and %s%s are set", name, name, fileSuffix)
+	case fromFile:
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read %s%s: %w", name, fileSuffix, err)
+		}
+		// Secret files usually end with newline
+		value = strings.TrimRight(string(b), "\r\n")
+	case !ok:
+		return nil
+	}
+	return f.set(value)
+}
+
+// Redacted returns copy of cfg with secrets hidden, so it can be shown
+func Redacted(cfg Configuration) Configuration {
+	for _, f := range fields(&cfg) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,177 @@
+package doconf
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+volume: /srv/docs
+trash:
+  retention: 720
+auth:
+  secret: from-yaml
+  access_ttl: 15
+service:
+  web:
+    host: localhost
+    port: 8080
+  db:
+    host: db.local
+    port: 5432
+    environment:
+      POSTGRES_USER: docshell
+      POSTGRES_PASSWORD: from-yaml
+`
+
+// Writes content into file in temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := writeFile(t, "config.yaml", testConfig)
+	t.Setenv("DOCSHELL_SERVICE_DB_HOST", "db.env")
+	t.Setenv("DOCSHELL_SERVICE_WEB_PORT", "9000")
+	t.Setenv("DOCSHELL_SERVICE_DB_MIGRATE", "true")
+	t.Setenv("DOCSHELL_AUTH_SECRET_FILE", writeFile(t, "secret", "from-file\n"))
+	t.Setenv("DOCSHELL_SERVICE_DB_ENVIRONMENT_POSTGRES_PASSWORD", "from-env")
+
+	cfg, err := Load(path, Flags{"service.web.port": "9100", "volume": "/mnt/docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{name: "kept from YAML", got: cfg.Service.Web.Host, want: "localhost"},
+		{name: "kept number from YAML", got: cfg.Auth.AccessTTL, want: 15},
+		{name: "environment", got: cfg.Service.DB.Host, want: "db.env"},
+		{name: "environment bool", got: cfg.Service.DB.Migrate, want: true},
+		{name: "environment of upper case key", got: cfg.Service.DB.Environment.PostgresPassword, want: "from-env"},
+		{name: "secret file without newline", got: cfg.Auth.Secret, want: "from-file"},
+		{name: "flag over environment", got: cfg.Service.Web.Port, want: 9100},
+		{name: "flag", got: cfg.Volume, want: "/mnt/docs"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadOverrideErrors(t *testing.T) {
+	path := writeFile(t, "config.yaml", testConfig)
+	tests := []struct {
+		name  string
+		env   map[string]string
+		flags Flags
+		err   string
+	}{
+		{
+			name: "value and file",
+			env:  map[string]string{"DOCSHELL_AUTH_SECRET": "a", "DOCSHELL_AUTH_SECRET_FILE": "/dev/null"},
+			err:  "both DOCSHELL_AUTH_SECRET and DOCSHELL_AUTH_SECRET_FILE are set",
+		},
+		{
+			name: "missing file",
+			env:  map[string]string{"DOCSHELL_AUTH_SECRET_FILE": filepath.Join(t.TempDir(), "missing")},
+			err:  "could not read DOCSHELL_AUTH_SECRET_FILE",
+		},
+		{
+			name: "environment not a number",
+			env:  map[string]string{"DOCSHELL_SERVICE_WEB_PORT": "http"},
+			err:  `value "http" of service.web.port incorrect`,
+		},
+		{
+			name:  "flag not a bool",
+			flags: Flags{"service.db.migrate": "maybe"},
+			err:   `value "maybe" of service.db.migrate incorrect`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			_, err := Load(path, tt.flags)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("docshell", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	flags := RegisterFlags(fs)
+	if err := fs.Parse([]string{"-service.db.port=5433", "-auth.admin.email", "root@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	want := Flags{"service.db.port": "5433", "auth.admin.email": "root@example.com"}
+	if len(flags) != len(want) {
+		t.Fatalf("RegisterFlags() = %v, want %v", flags, want)
+	}
+	for key, value := range want {
+		if flags[key] != value {
+			t.Errorf("flag %s = %q, want %q", key, flags[key], value)
+		}
+	}
+
+	// Values are checked on parse
+	fs = flag.NewFlagSet("docshell", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-service.web.port=web"}); err == nil {
+		t.Fatal("Parse() of port 'web' succeeded")
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("service.db.environment.postgres_password"); got != "DOCSHELL_SERVICE_DB_ENVIRONMENT_POSTGRES_PASSWORD" {
+		t.Errorf("EnvName() = %s", got)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	var cfg Configuration
+	cfg.Auth.Secret = "token key"
+	cfg.Auth.Admin.Password = "admin password"
+	cfg.Service.DB.Environment.PostgresPassword = "db password"
+	cfg.Storage.S3.AccessKey = "access"
+	cfg.Service.DB.Host = "db.local"
+
+	shown := Redacted(cfg)
+	for name, value := range map[string]string{
+		"auth.secret":         shown.Auth.Secret,
+		"auth.admin.password": shown.Auth.Admin.Password,
+		"postgres_password":   shown.Service.DB.Environment.PostgresPassword,
+		"s3.access_key":       shown.Storage.S3.AccessKey,
+	} {
+		if value != redacted {
+			t.Errorf("%s = %q, want %q", name, value, redacted)
+		}
+	}
+	// Empty secret shows it is not set
+	if shown.Storage.S3.SecretKey != "" {
+		t.Errorf("s3.secret_key = %q, want empty", shown.Storage.S3.SecretKey)
+	}
+	if shown.Service.DB.Host != "db.local" {
+		t.Errorf("service.db.host = %q, want %q", shown.Service.DB.Host, "db.local")
+	}
+	// Original is left intact
+	if cfg.Auth.Secret != "token key" {
+		t.Errorf("Redacted() changed original secret to %q", cfg.Auth.Secret)
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/utils"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+	// Longest search query in bytes
+	maxQueryLength = 256
+)
+
+// Reads filter, sort and page query params of document listing,
+// sends error response if any of them is incorrect
+func readDocumentFilter(w http.ResponseWriter, r *http.Request) (models.DocumentFilter, bool) {
+	q := r.URL.Query()
+	f := models.DocumentFilter{
+		Sort:   "id",
+		Cursor: q.Get("cursor"),
+	}
+	var ok bool
+
+	// Ids and sizes
+	ints := []struct {
+		name string
+		dst  **int64
+	}{
+		{"author_id", &f.AuthorId},
+		{"uploader_id", &f.UploaderId},
+		{"min_size", &f.MinSize},
+		{"max_size", &f.MaxSize},
+	}
+	for _, p := range ints {
+		if !readInt64(w, q, p.name, p.dst) {
+			return f, false
+		}
+	}
+	if f.MinSize != nil && f.MaxSize != nil && *f.MinSize > *f.MaxSize {
+		msg := "Query value 'min_size' is greater than 'max_size'"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return f, false
+	}
+
+	// Dates
+	times := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &f.CreatedAfter},
+		{"created_before", &f.CreatedBefore},
+		{"changed_after", &f.ChangedAfter},
+		{"changed_before", &f.ChangedBefore},
+	}
+	for _, p := range times {
+		if !readTime(w, q, p.name, p.dst) {
+			return f, false
+		}
+	}
+
+	// Text conditions
+	if q.Has("path") {
+		path := utils.CleanPath(q.Get("path"))
+		f.Path = &path
+	}
+	if title := q.Get("title"); title != "" {
+		f.Title = &title
+	}
+	if hash := q.Get("hash"); hash != "" {
+		f.Hash = &hash
+	}
+
+	// Sort column and order
+	if sort := q.Get("sort"); sort != "" {
+		if !slices.Contains(models.SortColumns, sort) {
+			msg := fmt.Sprintf("Query value 'sort=%v' incorrect, expected one of %v", sort, models.SortColumns)
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return f, false
+		}
+		f.Sort = sort
+	}
+	switch order := q.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		f.Desc = true
+	default:
+		msg := fmt.Sprintf("Query value 'order=%v' incorrect", order)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return f, false
+	}
+
+	// Page size
+	if f.Limit, ok = readLimit(w, r); !ok {
+		return f, false
+	}
+	return f, true
+}
+
+// Reads 'limit' query param, default page size is returned if it is not set
+func readLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultPageLimit, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || 0 >= limit || limit > maxPageLimit {
+		msg := fmt.Sprintf("Query value 'limit=%v' incorrect, expected up to %d", value, maxPageLimit)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, false
+	}
+	return limit, true
+}
+
+// Reads non-negative integer query param into dst if it is set
+func readInt64(w http.ResponseWriter, q url.Values, name string, dst **int64) bool {
+	value := q.Get(name)
+	if value == "" {
+		return true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || 0 > n {
+		msg := fmt.Sprintf("Query value '%v=%v' incorrect", name, value)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	*dst = &n
+	return true
+}
+
+// Reads RFC 3339 time query param into dst if it is set
+func readTime(w http.ResponseWriter, q url.Values, name string, dst **time.Time) bool {
+	value := q.Get(name)
+	if value == "" {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		msg := fmt.Sprintf("Query value '%v=%v' incorrect, expected RFC 3339 time", name, value)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	*dst = &t
+	return true
+}
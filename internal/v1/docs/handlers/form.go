@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"docshell/internal/v1/utils"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Maximum size of 'meta' form field
+const maxMetaSize = 1 << 20
+
+// readDocumentForm streams multipart form with 'meta' and 'file' fields.
+// File is staged into the volume, so caller must discard it when done.
+// On failure error response is already sent and ok is false.
+func (h *Handler) readDocumentForm(ctx context.Context, w http.ResponseWriter, r *http.Request) (
+	body []byte, file *utils.StagedFile, ok bool) {
+	maxSize := h.maxUploadSize
+	// Limit whole body, leaving room for meta and multipart boundaries
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+maxMetaSize)
+
+	// Read multipart form part by part without buffering it
+	reader, err := r.MultipartReader()
+	if err != nil {
+		msg := "During parsing multupart form"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return nil, nil, false
+	}
+
+	// Remove staged file if form is incorrect
+	defer func() {
+		if !ok {
+			utils.DiscardFile(file)
+		}
+	}()
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			msg := "During parsing multupart form"
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return nil, file, false
+		}
+
+		switch part.FormName() {
+		case "meta":
+			// Read body
+			body, err = io.ReadAll(io.LimitReader(part, maxMetaSize))
+			if err != nil {
+				msg := "Body can not be read"
+				utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+				return nil, file, false
+			}
+		case "file":
+			if file != nil {
+				msg := "Only one file can be uploaded"
+				utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+				return nil, file, false
+			}
+			// Stream file into volume
+			file, err = utils.StageFile(ctx, h.storage, part, part.FileName(), maxSize)
+			if errors.Is(err, utils.ErrFileTooLarge) {
+				msg := fmt.Sprintf("File exceeds %d MB", maxSize>>20)
+				utils.SendJSONErrorResponse(w, http.StatusRequestEntityTooLarge, msg)
+				return nil, nil, false
+			}
+			if err != nil {
+				msg := "Form file incorrect"
+				utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+				return nil, nil, false
+			}
+		}
+		part.Close()
+	}
+
+	return body, file, true
+}
+
+// readDocumentBody streams raw request body as file named filename.
+// File is staged into the volume, so caller must discard it when done.
+// On failure error response is already sent and ok is false.
+func (h *Handler) readDocumentBody(ctx context.Context, w http.ResponseWriter, r *http.Request, filename string) (
+	file *utils.StagedFile, ok bool) {
+	maxSize := h.maxUploadSize
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1)
+
+	// Stream file into volume
+	file, err := utils.StageFile(ctx, h.storage, r.Body, filename, maxSize)
+	if errors.Is(err, utils.ErrFileTooLarge) {
+		msg := fmt.Sprintf("File exceeds %d MB", maxSize>>20)
+		utils.SendJSONErrorResponse(w, http.StatusRequestEntityTooLarge, msg)
+		return nil, false
+	}
+	if err != nil {
+		msg := "File can not be read"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return nil, false
+	}
+	return file, true
+}
@@ -1,25 +1,78 @@
 package handlers
 
 import (
-	"context"
 	"docshell/internal/v1/docs/models"
 	"docshell/internal/v1/docs/service"
+	"docshell/internal/v1/policy"
 	"docshell/internal/v1/utils"
+	"docshell/internal/v1/volume"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 )
 
-func GetAllDocuments(w http.ResponseWriter, r *http.Request) {
+// Handler serves documents
+type Handler struct {
+	service *service.Service
+	// Uploads are staged into storage before they are saved
+	storage volume.Storage
+	// Maximum size of uploaded file in bytes
+	maxUploadSize int64
+}
+
+// New returns handler accepting uploads up to maxUploadSize bytes
+func New(service *service.Service, store volume.Storage, maxUploadSize int64) *Handler {
+	return &Handler{service: service, storage: store, maxUploadSize: maxUploadSize}
+}
+
+func (h *Handler) GetAllDocuments(w http.ResponseWriter, r *http.Request) {
+	// Read query param
+	embed, ok := readEmbed(w, r)
+	if !ok {
+		return
+	}
+	// Read filter, sort and page params
+	filter, ok := readDocumentFilter(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ListDocuments) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetAllDocuments(ctx, w, r, embed, filter)
+}
+
+func (h *Handler) SearchDocuments(w http.ResponseWriter, r *http.Request) {
+	// Read query params
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" || len(q) > maxQueryLength {
+		msg := fmt.Sprintf("Query value 'q' must be 1 to %d characters", maxQueryLength)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	limit, ok := readLimit(w, r)
+	if !ok {
+		return
+	}
 	// Set context for chain
-	ctx := context.Background()
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ListDocuments) {
+		return
+	}
 	// Call next function and pass context
-	service.GetAllDocuments(ctx, w, r)
+	h.service.SearchDocuments(ctx, w, r, q, limit)
 }
 
-func GetDocumentById(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetDocumentById(w http.ResponseWriter, r *http.Request) {
 	// Read path value
 	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil || 0 >= id {
@@ -27,56 +80,273 @@ func GetDocumentById(w http.ResponseWriter, r *http.Request) {
 		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
 		return
 	}
+	// Read query param
+	embed, ok := readEmbed(w, r)
+	if !ok {
+		return
+	}
 	// Set context for chain
-	ctx := context.Background()
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ReadDocument) {
+		return
+	}
 	// Call next function and pass context
-	service.GetDocumentById(ctx, w, r, id)
+	h.service.GetDocumentById(ctx, w, r, id, embed)
 }
 
-func CreateDocument(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form, specifies a maximum upload size.
-	if err := r.ParseMultipartForm(1 << 20); err != nil {
-		msg := "During parsing multupart form"
-		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+func (h *Handler) CreateDocument(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.CreateDocument) {
 		return
 	}
 
-	// Struct to put in it parsed body
-	var dc models.DocumentCreation
+	// Stream form, file is staged into volume
+	body, file, ok := h.readDocumentForm(ctx, w, r)
+	if !ok {
+		return
+	}
+	// Remove staged file if it was not committed
+	defer utils.DiscardFile(file)
 
-	// Read body
-	body := r.FormValue("meta")
-	if body == "" { // if empty
+	if len(body) == 0 { // if empty
 		msg := "Body is empty"
 		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
 		return
 	}
-
-	// FormFile returns the first file for the given key `file`
-	// it also returns the FileHeader so we can get the Filename,
-	// the Header and the size of the file
-	file, header, err := r.FormFile("file")
-	if err != nil {
+	if file == nil {
 		msg := "Form file incorrect"
 		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
 		return
 	}
-	defer file.Close()
 
+	// Struct to put in it parsed body
+	var dc models.DocumentCreation
 	// Try to decode body into the struct
-	if err := json.Unmarshal([]byte(body), &dc); err != nil {
+	if err := json.Unmarshal(body, &dc); err != nil {
 		msg := "JSON is incorrect"
 		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
 		return
 	}
 
+	// Call next function
+	h.service.CreateDocument(ctx, w, r, file, dc)
+}
+
+func (h *Handler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
 	// Set context for chain
-	ctx := context.Background()
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+
+	// Metadata may be sent as JSON body or as multipart
+	// form with optional replacement file
+	var body []byte
+	var file *utils.StagedFile
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		var ok bool
+		if body, file, ok = h.readDocumentForm(ctx, w, r); !ok {
+			return
+		}
+		// Remove staged file if it was not committed
+		defer utils.DiscardFile(file)
+	} else {
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxMetaSize))
+		if err != nil {
+			msg := "Body can not be read"
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	// Struct to put in it parsed body
+	var du models.DocumentUpdate
+	if len(body) != 0 {
+		// Try to decode body into the struct
+		if err := json.Unmarshal(body, &du); err != nil {
+			msg := "JSON is incorrect"
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	// PUT replaces all metadata, PATCH changes only sent fields
+	if r.Method == http.MethodPut &&
+		(du.AuthorId == nil || du.Title == nil || du.Path == nil) {
+		msg := "Fields 'author_id', 'title' and 'path' are required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if file == nil && du == (models.DocumentUpdate{}) {
+		msg := "Nothing to update"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
 	// Call next function
-	service.CreateDocument(ctx, w, r, file, header, dc)
+	h.service.UpdateDocument(ctx, w, r, id, file, du)
+}
+
+func (h *Handler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.DeleteDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.DeleteDocument(ctx, w, r, id)
 }
 
-func DownloadDocument(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.DeleteDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetTrash(ctx, w, r)
+}
+
+func (h *Handler) RestoreDocument(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.DeleteDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.RestoreDocument(ctx, w, r, id)
+}
+
+func (h *Handler) GetVersions(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ReadDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetVersions(ctx, w, r, id)
+}
+
+func (h *Handler) DownloadVersion(w http.ResponseWriter, r *http.Request) {
+	// Read path values
+	id, version, ok := readVersionPath(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.DownloadDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.DownloadVersion(ctx, w, r, id, version)
+}
+
+func (h *Handler) RevertDocument(w http.ResponseWriter, r *http.Request) {
+	// Read path values
+	id, version, ok := readVersionPath(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.RevertDocument(ctx, w, r, id, version)
+}
+
+// Reads 'embed' query param, only 'users' can be embedded
+func readEmbed(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	switch embed := r.URL.Query().Get("embed"); embed {
+	case "":
+		return false, true
+	case "users":
+		return true, true
+	default:
+		msg := fmt.Sprintf("Query value 'embed=%v' incorrect", embed)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false, false
+	}
+}
+
+// Reads 'id' and 'version' path values, sends error response if incorrect
+func readVersionPath(w http.ResponseWriter, r *http.Request) (id int, version int, ok bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, 0, false
+	}
+	version, err = strconv.Atoi(r.PathValue("version"))
+	if err != nil || 0 >= version {
+		msg := fmt.Sprintf("Path value 'version=%v' incorrect", version)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, 0, false
+	}
+	return id, version, true
+}
+
+func (h *Handler) DownloadDocumentById(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.DownloadDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.DownloadDocumentById(ctx, w, r, id)
+}
+
+// Deprecated: use DownloadDocumentById.
+func (h *Handler) DownloadDocument(w http.ResponseWriter, r *http.Request) {
 	// Read query param
 	path := r.URL.Query().Get("path")
 	if path == "" {
@@ -92,7 +362,11 @@ func DownloadDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Set context for chain
-	ctx := context.Background()
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.DownloadDocument) {
+		return
+	}
 	// Call next function and pass context
-	service.DownloadDocument(ctx, w, r, decoded)
+	h.service.DownloadDocument(ctx, w, r, decoded)
 }
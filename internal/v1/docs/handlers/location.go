@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) MoveDocument(w http.ResponseWriter, r *http.Request) {
+	// Read path value and body
+	id, loc, ok := readLocation(w, r)
+	if !ok {
+		return
+	}
+	if loc.Path == nil && loc.Title == nil {
+		msg := "At least one of fields 'path' and 'title' is required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.MoveDocument(ctx, w, r, id, loc)
+}
+
+func (h *Handler) RenameDocument(w http.ResponseWriter, r *http.Request) {
+	// Read path value and body
+	id, loc, ok := readLocation(w, r)
+	if !ok {
+		return
+	}
+	if loc.Title == nil || loc.Path != nil {
+		msg := "Only field 'title' is expected, use move to change 'path'"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.MoveDocument(ctx, w, r, id, loc)
+}
+
+func (h *Handler) CopyDocument(w http.ResponseWriter, r *http.Request) {
+	// Read path value and body
+	id, loc, ok := readLocation(w, r)
+	if !ok {
+		return
+	}
+	// Copy can not take place of the document
+	if loc.Path == nil && loc.Title == nil {
+		msg := "At least one of fields 'path' and 'title' is required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.CreateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.CopyDocument(ctx, w, r, id, loc)
+}
+
+// Reads 'id' path value and location body, sends error response if incorrect
+func readLocation(w http.ResponseWriter, r *http.Request) (int, models.DocumentLocation, bool) {
+	var loc models.DocumentLocation
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, loc, false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMetaSize))
+	if err != nil {
+		msg := "Body can not be read"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, loc, false
+	}
+	if len(body) == 0 { // if empty
+		msg := "Body is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, loc, false
+	}
+	// Try to decode body into the struct
+	if err := json.Unmarshal(body, &loc); err != nil {
+		msg := "JSON is incorrect"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, loc, false
+	}
+	return id, loc, true
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Lifetime of pre-signed URL if not requested
+	defaultPresignTTL = 15 * time.Minute
+	// Longest lifetime of pre-signed URL
+	maxPresignTTL = 7 * 24 * time.Hour
+)
+
+func (h *Handler) PresignDownload(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Read query param
+	ttl, ok := readTTL(w, r.URL.Query().Get("ttl"))
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.DownloadDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.PresignDownload(ctx, w, r, id, ttl)
+}
+
+func (h *Handler) PresignUpload(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.CreateDocument) {
+		return
+	}
+
+	// Read body
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMetaSize))
+	if err != nil {
+		msg := "Body can not be read"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if len(body) == 0 { // if empty
+		msg := "Body is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Struct to put in it parsed body
+	var pu models.PresignUpload
+	// Try to decode body into the struct
+	if err := json.Unmarshal(body, &pu); err != nil {
+		msg := "JSON is incorrect"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	ttl, ok := readTTL(w, strconv.Itoa(pu.TTL))
+	if !ok {
+		return
+	}
+
+	// Call next function
+	h.service.PresignUpload(ctx, w, r, pu, ttl)
+}
+
+// UploadPresigned creates document from body of PUT to pre-signed URL,
+// query values are covered by signature checked by middleware
+func (h *Handler) UploadPresigned(w http.ResponseWriter, r *http.Request) {
+	// Read query params
+	query := r.URL.Query()
+	authorId, err := strconv.ParseInt(query.Get("author_id"), 10, 64)
+	if err != nil || 0 >= authorId {
+		msg := fmt.Sprintf("Query value 'author_id=%v' incorrect", query.Get("author_id"))
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		msg := fmt.Sprintf("Query value 'expires=%v' incorrect", query.Get("expires"))
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Role of user who signed URL may have changed since
+	if !policy.Authorize(ctx, w, policy.CreateDocument) {
+		return
+	}
+	// Stream body, file is staged into volume
+	file, ok := h.readDocumentBody(ctx, w, r, query.Get("title"))
+	if !ok {
+		return
+	}
+	// Remove staged file if it was not committed
+	defer utils.DiscardFile(file)
+
+	// Call next function, URL accepts single upload
+	h.service.UploadPresigned(ctx, w, r, file, models.DocumentCreation{
+		AuthorId: authorId,
+		Path:     query.Get("path"),
+	}, query.Get("nonce"), time.Unix(expires, 0))
+}
+
+// Parses lifetime in seconds, default one is used for empty or zero value
+func readTTL(w http.ResponseWriter, value string) (time.Duration, bool) {
+	if value == "" || value == "0" {
+		return defaultPresignTTL, true
+	}
+	seconds, err := strconv.Atoi(value)
+	ttl := time.Duration(seconds) * time.Second
+	if err != nil || 0 >= seconds || ttl > maxPresignTTL {
+		msg := fmt.Sprintf("TTL '%v' incorrect, expected seconds up to %d", value, int(maxPresignTTL.Seconds()))
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, false
+	}
+	return ttl, true
+}
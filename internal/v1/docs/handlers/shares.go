@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+
+	// Body is optional, share without it is not limited
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMetaSize))
+	if err != nil {
+		msg := "Body can not be read"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Struct to put in it parsed body
+	var sc models.ShareCreation
+	if len(body) != 0 {
+		// Try to decode body into the struct
+		if err := json.Unmarshal(body, &sc); err != nil {
+			msg := "JSON is incorrect"
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	// Call next function
+	h.service.CreateShare(ctx, w, r, id, sc)
+}
+
+func (h *Handler) GetShares(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetShares(ctx, w, r, id)
+}
+
+func (h *Handler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	// Read path values
+	id, shareId, ok := readSharePath(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.RevokeShare(ctx, w, r, id, shareId)
+}
+
+func (h *Handler) GetShareDownloads(w http.ResponseWriter, r *http.Request) {
+	// Read path values
+	id, shareId, ok := readSharePath(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetShareDownloads(ctx, w, r, id, shareId)
+}
+
+// DownloadShare is public, share token authorizes request
+func (h *Handler) DownloadShare(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	token := r.PathValue("token")
+	if token == "" {
+		msg := "Path value 'token' is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.DownloadShare(ctx, w, r, token)
+}
+
+// Reads 'id' and 'share_id' path values, sends error response if incorrect
+func readSharePath(w http.ResponseWriter, r *http.Request) (id int, shareId int64, ok bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", id)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, 0, false
+	}
+	shareId, err = strconv.ParseInt(r.PathValue("share_id"), 10, 64)
+	if err != nil || 0 >= shareId {
+		msg := fmt.Sprintf("Path value 'share_id=%v' incorrect", r.PathValue("share_id"))
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, 0, false
+	}
+	return id, shareId, true
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Document struct {
 	Id         int64  `json:"id" db:"id"`
 	AuthorId   int64  `json:"author_id" db:"author_id"`
@@ -8,22 +10,178 @@ type Document struct {
 	Size       int64  `json:"size" db:"size"`
 	Path       string `json:"path" db:"path"`
 	Hash       string `json:"hash" db:"hash"`
-	CreatedAt  string `json:"created_at" db:"created_at"`
-	ChangedAt  string `json:"changed_at" db:"changed_at"`
+	// MIME type detected on upload
+	ContentType string `json:"content_type" db:"content_type"`
+	CreatedAt   string `json:"created_at" db:"created_at"`
+	ChangedAt   string `json:"changed_at" db:"changed_at"`
+	// Set when document is in trash
+	DeletedAt *string `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// Embedded on request with '?embed=users'
+	Author   *UserRef `json:"author,omitempty"`
+	Uploader *UserRef `json:"uploader,omitempty"`
+}
+
+// Short description of user embedded into document
+type UserRef struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
 }
 
 type DocumentCreation struct {
-	AuthorId   int64  `json:"author_id" db:"author_id"`
-	UploaderId int64  `json:"uploader_id" db:"uploader_id"`
+	AuthorId int64 `json:"author_id" db:"author_id"`
+	// Set to authenticated user, value sent by client is ignored
+	UploaderId int64  `json:"-" db:"uploader_id"`
 	Title      string `json:"title" db:"title"`
 	Size       int64  `json:"size" db:"size"`
 	Path       string `json:"path" db:"path"`
 	Hash       string `json:"hash" db:"hash"`
+	// Detected by server, value sent by client is ignored
+	ContentType string `json:"-" db:"content_type"`
+}
+
+// Fields left nil are not changed
+type DocumentUpdate struct {
+	AuthorId *int64  `json:"author_id"`
+	Title    *string `json:"title"`
+	Path     *string `json:"path"`
+	// Set to authenticated user when content is replaced
+	UploaderId *int64 `json:"-"`
+}
+
+// Columns document listing can be sorted by
+var SortColumns = []string{"id", "title", "path", "size", "created_at", "changed_at"}
+
+// Document found by search
+type SearchResult struct {
+	Document
+	// Relevance to query, higher is better
+	Rank float64 `json:"rank"`
+	// Fragments of title and content, matches are wrapped
+	// into <mark> tags, other text is HTML escaped
+	Snippet string `json:"snippet"`
+}
+
+// Document waiting for text extraction
+type UnindexedDocument struct {
+	Id          int64
+	Hash        string
+	ContentType string
+}
+
+// File of document saved under its path and
+// title before blob store existed
+type LegacyFile struct {
+	Path  string
+	Title string
+	Hash  string
+}
+
+// Conditions of document listing, fields left nil are not checked
+type DocumentFilter struct {
+	AuthorId   *int64
+	UploaderId *int64
+	// Folder containing documents, subfolders included
+	Path *string
+	// Case insensitive part of title
+	Title   *string
+	Hash    *string
+	MinSize *int64
+	MaxSize *int64
+	// Ranges of creation and change time, bounds are inclusive
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	ChangedAfter  *time.Time
+	ChangedBefore *time.Time
+
+	// Column documents are ordered by, ties are ordered by id
+	Sort string
+	Desc bool
+	// Maximum number of documents in page
+	Limit int
+	// Opaque position after which page starts, empty for first page
+	Cursor string
+}
+
+// New place of moved, renamed or copied document,
+// fields left nil are kept from the document
+type DocumentLocation struct {
+	Path  *string `json:"path"`
+	Title *string `json:"title"`
+}
+
+// Previous content of document kept on file replacement
+type DocumentVersion struct {
+	Id          int64  `json:"id" db:"id"`
+	DocumentId  int64  `json:"document_id" db:"document_id"`
+	Version     int    `json:"version" db:"version"`
+	Size        int64  `json:"size" db:"size"`
+	Hash        string `json:"hash" db:"hash"`
+	ContentType string `json:"content_type" db:"content_type"`
+	CreatedAt   string `json:"created_at" db:"created_at"`
+}
+
+// Public link to document, token itself is stored only as hash
+type Share struct {
+	Id         int64 `json:"id" db:"id"`
+	DocumentId int64 `json:"document_id" db:"document_id"`
+	CreatedBy  int64 `json:"created_by" db:"created_by"`
+	// Leading characters of token to recognize it
+	Prefix string `json:"prefix" db:"prefix"`
+	// Link never expires if nil
+	ExpiresAt *string `json:"expires_at" db:"expires_at"`
+	// Downloads are not limited if nil
+	MaxDownloads  *int    `json:"max_downloads" db:"max_downloads"`
+	DownloadCount int     `json:"download_count" db:"download_count"`
+	PasswordHash  *string `json:"-" db:"password_hash"`
+	CreatedAt     string  `json:"created_at" db:"created_at"`
+	// Set when share is revoked
+	RevokedAt *string `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// Fields left nil are not limited
+type ShareCreation struct {
+	ExpiresAt    *time.Time `json:"expires_at"`
+	MaxDownloads *int       `json:"max_downloads"`
+	Password     *string    `json:"password"`
+}
+
+// Download made through share link
+type ShareDownload struct {
+	Id           int64  `json:"id" db:"id"`
+	ShareId      int64  `json:"share_id" db:"share_id"`
+	RemoteAddr   string `json:"remote_addr" db:"remote_addr"`
+	UserAgent    string `json:"user_agent" db:"user_agent"`
+	DownloadedAt string `json:"downloaded_at" db:"downloaded_at"`
+}
+
+// Request of URL for single upload of file into path and title
+type PresignUpload struct {
+	AuthorId int64  `json:"author_id"`
+	Title    string `json:"title"`
+	Path     string `json:"path"`
+	// Lifetime of URL in seconds
+	TTL int `json:"ttl"`
+}
+
+// Content addressed file shared by documents and versions
+type Blob struct {
+	Hash      string `json:"hash" db:"hash"`
+	Size      int64  `json:"size" db:"size"`
+	RefCount  int    `json:"ref_count" db:"ref_count"`
+	CreatedAt string `json:"created_at" db:"created_at"`
 }
 
 type ResponseMultipleDocuments struct {
 	StatusCode int        `json:"status_code"`
 	Documents  []Document `json:"documents"`
+	// Cursor of next page, omitted on last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ResponseSearchResults struct {
+	StatusCode int            `json:"status_code"`
+	Results    []SearchResult `json:"results"`
 }
 
 type ResponseSingleDocument struct {
@@ -40,3 +198,39 @@ type ErrorResponse struct {
 	StatusText string `json:"text"`
 	Message    string `json:"msg"`
 }
+
+type ResponseMultipleShares struct {
+	StatusCode int     `json:"status_code"`
+	Shares     []Share `json:"shares"`
+}
+
+type ResponseSingleShare struct {
+	StatusCode int   `json:"status_code"`
+	Share      Share `json:"share"`
+}
+
+// Sent once on creation, token can not be read later
+type ResponseCreatedShare struct {
+	StatusCode int    `json:"status_code"`
+	Share      Share  `json:"share"`
+	Token      string `json:"token"`
+	// Path of public download link
+	Url string `json:"url"`
+}
+
+type ResponseMultipleShareDownloads struct {
+	StatusCode int             `json:"status_code"`
+	Downloads  []ShareDownload `json:"downloads"`
+}
+
+type ResponsePresignedUrl struct {
+	StatusCode int    `json:"status_code"`
+	Method     string `json:"method"`
+	Url        string `json:"url"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+type ResponseMultipleVersions struct {
+	StatusCode int               `json:"status_code"`
+	Versions   []DocumentVersion `json:"versions"`
+}
@@ -5,8 +5,73 @@ import "database/sql"
 func ScanDocument(rows *sql.Rows) (Document, error) {
 	doc := Document{}
 	if err := rows.Scan(&doc.Id, &doc.AuthorId, &doc.UploaderId,
-		&doc.Title, &doc.Size, &doc.Path, &doc.Hash, &doc.CreatedAt, &doc.ChangedAt); err != nil {
+		&doc.Title, &doc.Size, &doc.Path, &doc.Hash, &doc.ContentType,
+		&doc.CreatedAt, &doc.ChangedAt, &doc.DeletedAt); err != nil {
 		return Document{}, err
 	}
 	return doc, nil
 }
+
+func ScanSearchResult(rows *sql.Rows) (SearchResult, error) {
+	res := SearchResult{}
+	doc := &res.Document
+	if err := rows.Scan(&doc.Id, &doc.AuthorId, &doc.UploaderId,
+		&doc.Title, &doc.Size, &doc.Path, &doc.Hash, &doc.ContentType,
+		&doc.CreatedAt, &doc.ChangedAt, &doc.DeletedAt,
+		&res.Rank, &res.Snippet); err != nil {
+		return SearchResult{}, err
+	}
+	return res, nil
+}
+
+func ScanUnindexedDocument(rows *sql.Rows) (UnindexedDocument, error) {
+	doc := UnindexedDocument{}
+	if err := rows.Scan(&doc.Id, &doc.Hash, &doc.ContentType); err != nil {
+		return UnindexedDocument{}, err
+	}
+	return doc, nil
+}
+
+func ScanVersion(rows *sql.Rows) (DocumentVersion, error) {
+	v := DocumentVersion{}
+	if err := rows.Scan(&v.Id, &v.DocumentId, &v.Version,
+		&v.Size, &v.Hash, &v.ContentType, &v.CreatedAt); err != nil {
+		return DocumentVersion{}, err
+	}
+	return v, nil
+}
+
+func ScanShare(rows *sql.Rows) (Share, error) {
+	s := Share{}
+	if err := rows.Scan(&s.Id, &s.DocumentId, &s.CreatedBy, &s.Prefix,
+		&s.ExpiresAt, &s.MaxDownloads, &s.DownloadCount, &s.PasswordHash,
+		&s.CreatedAt, &s.RevokedAt); err != nil {
+		return Share{}, err
+	}
+	return s, nil
+}
+
+func ScanShareDownload(rows *sql.Rows) (ShareDownload, error) {
+	d := ShareDownload{}
+	if err := rows.Scan(&d.Id, &d.ShareId, &d.RemoteAddr,
+		&d.UserAgent, &d.DownloadedAt); err != nil {
+		return ShareDownload{}, err
+	}
+	return d, nil
+}
+
+func ScanBlob(rows *sql.Rows) (Blob, error) {
+	b := Blob{}
+	if err := rows.Scan(&b.Hash, &b.Size, &b.RefCount, &b.CreatedAt); err != nil {
+		return Blob{}, err
+	}
+	return b, nil
+}
+
+func ScanLegacyFile(rows *sql.Rows) (LegacyFile, error) {
+	f := LegacyFile{}
+	if err := rows.Scan(&f.Path, &f.Title, &f.Hash); err != nil {
+		return LegacyFile{}, err
+	}
+	return f, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/storage"
+)
+
+// AcquireBlob takes reference on blob, creating its record if needed
+func AcquireBlob(ctx context.Context, con storage.Executor, hash string, size int64) (models.Blob, error) {
+	rows, err := con.QueryContext(ctx, acquire_blob, hash, size)
+	if err != nil {
+		return models.Blob{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanBlob)
+}
+
+// ReleaseBlob drops reference on blob
+func ReleaseBlob(ctx context.Context, con storage.Executor, hash string) error {
+	_, err := con.ExecContext(ctx, release_blob, hash)
+	return err
+}
+
+// GetBlob returns blob with given hash, empty one if it has no record
+func GetBlob(ctx context.Context, con storage.Executor, hash string) (models.Blob, error) {
+	rows, err := con.QueryContext(ctx, get_blob, hash)
+	if err != nil {
+		return models.Blob{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanBlob)
+}
+
+// DeleteUnreferencedBlobs deletes records of blobs without references
+// and returns their hashes. Files of blobs are removed after that, so
+// blob is never acquired while its file is being removed.
+func DeleteUnreferencedBlobs(ctx context.Context, con storage.Executor) ([]string, error) {
+	rows, err := con.QueryContext(ctx, delete_unreferenced_blobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, scanHash)
+}
+
+// GetLegacyFiles returns files of documents saved under
+// their path and title before blob store existed
+func GetLegacyFiles(ctx context.Context, con *sql.DB) ([]models.LegacyFile, error) {
+	rows, err := con.QueryContext(ctx, get_legacy_files)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanLegacyFile)
+}
+
+// DeleteLegacyFile forgets file moved into blob store
+func DeleteLegacyFile(ctx context.Context, con *sql.DB, f models.LegacyFile) error {
+	_, err := con.ExecContext(ctx, delete_legacy_file, f.Path, f.Title)
+	return err
+}
+
+func scanHash(rows *sql.Rows) (string, error) {
+	var hash string
+	err := rows.Scan(&hash)
+	return hash, err
+}
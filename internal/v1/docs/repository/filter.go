@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"docshell/internal/v1/docs/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Columns documents can be sorted by with SQL type of their values
+var sortColumns = map[string]string{
+	"id":         "bigint",
+	"title":      "text",
+	"path":       "text",
+	"size":       "bigint",
+	"created_at": "timestamptz",
+	"changed_at": "timestamptz",
+}
+
+// Position in listing: sort value and id of last document of page.
+// Sort and order are kept to reject cursor used with other ones.
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	Id    int64  `json:"i"`
+}
+
+// Builds query listing documents visible to user matching filter
+func buildDocumentsQuery(userId int64, all bool, f models.DocumentFilter) (string, []any, error) {
+	typ, ok := sortColumns[f.Sort]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown sort column %q", f.Sort)
+	}
+
+	var b strings.Builder
+	b.WriteString(get_visible_documents)
+	args := []any{all, userId}
+	// Adds argument and returns its placeholder
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if f.AuthorId != nil {
+		fmt.Fprintf(&b, " and d.author_id = %s", arg(*f.AuthorId))
+	}
+	if f.UploaderId != nil {
+		fmt.Fprintf(&b, " and d.uploader_id = %s", arg(*f.UploaderId))
+	}
+	// Root folder contains every document
+	if f.Path != nil && *f.Path != "." {
+		p := arg(*f.Path)
+		fmt.Fprintf(&b, " and (d.path = %s or starts_with(d.path, %s || '/'))", p, p)
+	}
+	if f.Title != nil {
+		fmt.Fprintf(&b, " and strpos(lower(d.title), lower(%s)) > 0", arg(*f.Title))
+	}
+	if f.Hash != nil {
+		fmt.Fprintf(&b, " and d.hash = %s", arg(*f.Hash))
+	}
+	if f.MinSize != nil {
+		fmt.Fprintf(&b, " and d.size >= %s", arg(*f.MinSize))
+	}
+	if f.MaxSize != nil {
+		fmt.Fprintf(&b, " and d.size <= %s", arg(*f.MaxSize))
+	}
+	if f.CreatedAfter != nil {
+		fmt.Fprintf(&b, " and d.created_at >= %s", arg(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		fmt.Fprintf(&b, " and d.created_at <= %s", arg(*f.CreatedBefore))
+	}
+	if f.ChangedAfter != nil {
+		fmt.Fprintf(&b, " and d.changed_at >= %s", arg(*f.ChangedAfter))
+	}
+	if f.ChangedBefore != nil {
+		fmt.Fprintf(&b, " and d.changed_at <= %s", arg(*f.ChangedBefore))
+	}
+
+	order, cmp := "asc", ">"
+	if f.Desc {
+		order, cmp = "desc", "<"
+	}
+	// Page starts after position of cursor
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil || c.Sort != f.Sort || c.Desc != f.Desc {
+			return "", nil, ErrInvalidCursor
+		}
+		fmt.Fprintf(&b, " and (d.%s, d.id) %s (%s::%s, %s)",
+			f.Sort, cmp, arg(c.Value), typ, arg(c.Id))
+	}
+
+	// Column name comes from whitelist, so it is safe to put into query
+	fmt.Fprintf(&b, " order by d.%s %s, d.id %s limit %s;", f.Sort, order, order, arg(f.Limit+1))
+	return b.String(), args, nil
+}
+
+// Encodes position of doc as cursor for listing ordered as in filter
+func encodeCursor(f models.DocumentFilter, doc models.Document) string {
+	c := cursor{Sort: f.Sort, Desc: f.Desc, Id: doc.Id}
+	switch f.Sort {
+	case "id":
+		c.Value = strconv.FormatInt(doc.Id, 10)
+	case "title":
+		c.Value = doc.Title
+	case "path":
+		c.Value = doc.Path
+	case "size":
+		c.Value = strconv.FormatInt(doc.Size, 10)
+	case "created_at":
+		c.Value = doc.CreatedAt
+	case "changed_at":
+		c.Value = doc.ChangedAt
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"docshell/internal/v1/docs/models"
+	"time"
+)
+
+// DocumentRepository keeps documents, their versions and references on
+// blobs. Postgres is the implementation the service runs with, since
+// users, grants, shares, search and folders are kept in Postgres too.
+// Memory keeps documents alone, it serves unit tests of the service.
+type DocumentRepository interface {
+	// GetAllDocuments returns page of documents visible to user matching
+	// filter and cursor of next page, see GetAllDocuments function
+	GetAllDocuments(ctx context.Context, userId int64, all bool, f models.DocumentFilter) ([]models.Document, string, error)
+	GetDocumentById(ctx context.Context, id int) (models.Document, error)
+	// GetDocumentForUpdate reads document like GetDocumentById and keeps
+	// it from concurrent changes until Atomic it is called in ends
+	GetDocumentForUpdate(ctx context.Context, id int) (models.Document, error)
+	GetDocumentByLocation(ctx context.Context, path, title string) (models.Document, error)
+	// GetDocumentPermission returns 'read', 'write' or empty string
+	GetDocumentPermission(ctx context.Context, id int, userId int64) (string, error)
+	// GetFolderDocuments returns documents visible to user directly in folder with path
+	GetFolderDocuments(ctx context.Context, userId int64, all bool, path string) ([]models.Document, error)
+	// GetDocumentPaths returns paths of documents other than the root, in trash too
+	GetDocumentPaths(ctx context.Context) ([]string, error)
+	CreateDocument(ctx context.Context, dc models.DocumentCreation) (models.Document, error)
+	UpdateDocument(ctx context.Context, id int, dc models.DocumentCreation) (models.Document, error)
+	RemoveDocument(ctx context.Context, id int64) error
+
+	GetVersions(ctx context.Context, id int) ([]models.DocumentVersion, error)
+	GetVersion(ctx context.Context, id int, version int) (models.DocumentVersion, error)
+	CreateVersion(ctx context.Context, doc models.Document) (models.DocumentVersion, error)
+
+	GetDeletedDocuments(ctx context.Context, userId int64, all bool) ([]models.Document, error)
+	GetExpiredDocuments(ctx context.Context, before time.Time) ([]models.Document, error)
+	TrashDocument(ctx context.Context, id int) (models.Document, error)
+	RestoreDocument(ctx context.Context, id int) (models.Document, error)
+	PurgeDocument(ctx context.Context, id int64) error
+
+	AcquireBlob(ctx context.Context, hash string, size int64) (models.Blob, error)
+	ReleaseBlob(ctx context.Context, hash string) error
+	// GetBlob returns empty blob if there is no record of it
+	GetBlob(ctx context.Context, hash string) (models.Blob, error)
+	// DeleteUnreferencedBlobs deletes records of blobs
+	// without references and returns their hashes
+	DeleteUnreferencedBlobs(ctx context.Context) ([]string, error)
+
+	// ClaimPresignNonce marks nonce of pre-signed URL as used,
+	// false is returned if it was used before
+	ClaimPresignNonce(ctx context.Context, nonce string, expiresAt time.Time) (bool, error)
+
+	// EnsureFolders creates folder with path and its parents unless they
+	// exist, created folders have no owner. Repositories without folders
+	// do nothing.
+	EnsureFolders(ctx context.Context, path string) error
+
+	// Atomic runs fn with repository whose changes are kept
+	// only if fn returns nil
+	Atomic(ctx context.Context, fn func(repo DocumentRepository) error) error
+}
@@ -0,0 +1,547 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/storage"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Layout of timestamps kept by Memory, it has fixed
+// width so timestamps are ordered as strings
+const timeLayout = "2006-01-02T15:04:05.000000Z"
+
+// Memory is DocumentRepository keeping everything in maps, it is meant for
+// unit tests. There are no grants, documents are visible to uploaders only.
+type Memory struct {
+	mu sync.Mutex
+	// Serializes Atomic calls
+	txMu sync.Mutex
+
+	documents map[int64]models.Document
+	versions  map[int64]models.DocumentVersion
+	blobs     map[string]models.Blob
+	// Used nonces of pre-signed URLs
+	nonces map[string]time.Time
+	lastId int64
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		documents: map[int64]models.Document{},
+		versions:  map[int64]models.DocumentVersion{},
+		blobs:     map[string]models.Blob{},
+		nonces:    map[string]time.Time{},
+	}
+}
+
+// Repository passed to fn of Atomic, nested calls join the outer one
+type memoryTx struct {
+	*Memory
+}
+
+func (t memoryTx) Atomic(ctx context.Context, fn func(repo DocumentRepository) error) error {
+	return fn(t)
+}
+
+func (m *Memory) GetAllDocuments(ctx context.Context, userId int64, all bool,
+	f models.DocumentFilter) ([]models.Document, string, error) {
+	if _, ok := sortColumns[f.Sort]; !ok {
+		return nil, "", fmt.Errorf("unknown sort column %q", f.Sort)
+	}
+	var after *cursor
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil || c.Sort != f.Sort || c.Desc != f.Desc {
+			return nil, "", ErrInvalidCursor
+		}
+		after = &c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var docs []models.Document
+	for _, doc := range m.documents {
+		if doc.DeletedAt != nil || !(all || doc.UploaderId == userId) || !matchesFilter(doc, f) {
+			continue
+		}
+		// Page starts after position of cursor
+		if after != nil {
+			c, err := compareSortValue(doc, f.Sort, after.Value)
+			if err != nil {
+				return nil, "", ErrInvalidCursor
+			}
+			if c == 0 {
+				c = cmp.Compare(doc.Id, after.Id)
+			}
+			if f.Desc {
+				c = -c
+			}
+			if c <= 0 {
+				continue
+			}
+		}
+		docs = append(docs, doc)
+	}
+
+	slices.SortFunc(docs, func(a, b models.Document) int {
+		c := cmp.Compare(sortValue(a, f.Sort), sortValue(b, f.Sort))
+		if f.Sort == "id" || f.Sort == "size" {
+			c = cmp.Compare(sortNumber(a, f.Sort), sortNumber(b, f.Sort))
+		}
+		if c == 0 {
+			c = cmp.Compare(a.Id, b.Id)
+		}
+		if f.Desc {
+			return -c
+		}
+		return c
+	})
+
+	if len(docs) <= f.Limit {
+		return docs, "", nil
+	}
+	docs = docs[:f.Limit]
+	return docs, encodeCursor(f, docs[len(docs)-1]), nil
+}
+
+func (m *Memory) GetDocumentById(ctx context.Context, id int) (models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	doc, ok := m.documents[int64(id)]
+	if !ok || doc.DeletedAt != nil {
+		return models.Document{}, nil
+	}
+	return doc, nil
+}
+
+// GetDocumentForUpdate reads document, Atomic calls are
+// serialized, so there is nothing to lock
+func (m *Memory) GetDocumentForUpdate(ctx context.Context, id int) (models.Document, error) {
+	return m.GetDocumentById(ctx, id)
+}
+
+func (m *Memory) GetDocumentByLocation(ctx context.Context, path, title string) (models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, doc := range m.documents {
+		if doc.DeletedAt == nil && doc.Path == path && doc.Title == title {
+			return doc, nil
+		}
+	}
+	return models.Document{}, nil
+}
+
+// GetDocumentPermission gives uploader write permission, nobody else has any
+func (m *Memory) GetDocumentPermission(ctx context.Context, id int, userId int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if doc, ok := m.documents[int64(id)]; ok && doc.UploaderId == userId {
+		return aclModels.PermissionWrite, nil
+	}
+	return "", nil
+}
+
+func (m *Memory) GetFolderDocuments(ctx context.Context, userId int64, all bool, path string) ([]models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var docs []models.Document
+	for _, doc := range m.documents {
+		if doc.DeletedAt == nil && (all || doc.UploaderId == userId) && doc.Path == path {
+			docs = append(docs, doc)
+		}
+	}
+	slices.SortFunc(docs, func(a, b models.Document) int {
+		return cmp.Or(cmp.Compare(a.Title, b.Title), cmp.Compare(a.Id, b.Id))
+	})
+	return docs, nil
+}
+
+func (m *Memory) GetDocumentPaths(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var paths []string
+	for _, doc := range m.documents {
+		if doc.Path != "." && !slices.Contains(paths, doc.Path) {
+			paths = append(paths, doc.Path)
+		}
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+func (m *Memory) CreateDocument(ctx context.Context, dc models.DocumentCreation) (models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.locationTaken(dc.Path, dc.Title, 0) {
+		return models.Document{}, storage.ErrUniqueViolation
+	}
+	m.lastId++
+	now := now()
+	doc := models.Document{
+		Id:          m.lastId,
+		AuthorId:    dc.AuthorId,
+		UploaderId:  dc.UploaderId,
+		Title:       dc.Title,
+		Size:        dc.Size,
+		Path:        dc.Path,
+		Hash:        dc.Hash,
+		ContentType: dc.ContentType,
+		CreatedAt:   now,
+		ChangedAt:   now,
+	}
+	m.documents[doc.Id] = doc
+	return doc, nil
+}
+
+func (m *Memory) UpdateDocument(ctx context.Context, id int, dc models.DocumentCreation) (models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	doc, ok := m.documents[int64(id)]
+	if !ok || doc.DeletedAt != nil {
+		return models.Document{}, nil
+	}
+	if m.locationTaken(dc.Path, dc.Title, doc.Id) {
+		return models.Document{}, storage.ErrUniqueViolation
+	}
+	doc.AuthorId = dc.AuthorId
+	doc.Title = dc.Title
+	doc.Size = dc.Size
+	doc.Path = dc.Path
+	doc.Hash = dc.Hash
+	doc.ContentType = dc.ContentType
+	doc.ChangedAt = now()
+	m.documents[doc.Id] = doc
+	return doc, nil
+}
+
+func (m *Memory) RemoveDocument(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeDocument(id)
+	return nil
+}
+
+func (m *Memory) GetVersions(ctx context.Context, id int) ([]models.DocumentVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var versions []models.DocumentVersion
+	for _, v := range m.versions {
+		if v.DocumentId == int64(id) {
+			versions = append(versions, v)
+		}
+	}
+	slices.SortFunc(versions, func(a, b models.DocumentVersion) int {
+		return cmp.Compare(b.Version, a.Version)
+	})
+	return versions, nil
+}
+
+func (m *Memory) GetVersion(ctx context.Context, id int, version int) (models.DocumentVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, v := range m.versions {
+		if v.DocumentId == int64(id) && v.Version == version {
+			return v, nil
+		}
+	}
+	return models.DocumentVersion{}, nil
+}
+
+func (m *Memory) CreateVersion(ctx context.Context, doc models.Document) (models.DocumentVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v := models.DocumentVersion{
+		DocumentId:  doc.Id,
+		Version:     1,
+		Size:        doc.Size,
+		Hash:        doc.Hash,
+		ContentType: doc.ContentType,
+		CreatedAt:   now(),
+	}
+	for _, other := range m.versions {
+		if other.DocumentId == doc.Id && other.Version >= v.Version {
+			v.Version = other.Version + 1
+		}
+	}
+	m.lastId++
+	v.Id = m.lastId
+	m.versions[v.Id] = v
+	return v, nil
+}
+
+func (m *Memory) GetDeletedDocuments(ctx context.Context, userId int64, all bool) ([]models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var docs []models.Document
+	for _, doc := range m.documents {
+		if doc.DeletedAt != nil && (all || doc.UploaderId == userId) {
+			docs = append(docs, doc)
+		}
+	}
+	slices.SortFunc(docs, func(a, b models.Document) int {
+		return strings.Compare(*b.DeletedAt, *a.DeletedAt)
+	})
+	return docs, nil
+}
+
+func (m *Memory) GetExpiredDocuments(ctx context.Context, before time.Time) ([]models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limit := before.UTC().Format(timeLayout)
+	var docs []models.Document
+	for _, doc := range m.documents {
+		if doc.DeletedAt != nil && *doc.DeletedAt < limit {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+func (m *Memory) TrashDocument(ctx context.Context, id int) (models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	doc, ok := m.documents[int64(id)]
+	if !ok || doc.DeletedAt != nil {
+		return models.Document{}, nil
+	}
+	now := now()
+	doc.DeletedAt = &now
+	m.documents[doc.Id] = doc
+	return doc, nil
+}
+
+func (m *Memory) RestoreDocument(ctx context.Context, id int) (models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	doc, ok := m.documents[int64(id)]
+	if !ok || doc.DeletedAt == nil {
+		return models.Document{}, nil
+	}
+	if m.locationTaken(doc.Path, doc.Title, doc.Id) {
+		return models.Document{}, storage.ErrUniqueViolation
+	}
+	doc.DeletedAt = nil
+	m.documents[doc.Id] = doc
+	return doc, nil
+}
+
+func (m *Memory) PurgeDocument(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	doc, ok := m.documents[id]
+	if !ok || doc.DeletedAt == nil {
+		return nil
+	}
+	// Release every reference of document and its versions
+	m.releaseBlob(doc.Hash)
+	for _, v := range m.versions {
+		if v.DocumentId == id {
+			m.releaseBlob(v.Hash)
+		}
+	}
+	m.removeDocument(id)
+	return nil
+}
+
+func (m *Memory) AcquireBlob(ctx context.Context, hash string, size int64) (models.Blob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	blob, ok := m.blobs[hash]
+	if !ok {
+		blob = models.Blob{
+			Hash:      hash,
+			Size:      size,
+			CreatedAt: now(),
+		}
+	}
+	blob.RefCount++
+	m.blobs[hash] = blob
+	return blob, nil
+}
+
+func (m *Memory) ReleaseBlob(ctx context.Context, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.releaseBlob(hash)
+	return nil
+}
+
+func (m *Memory) GetBlob(ctx context.Context, hash string) (models.Blob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.blobs[hash], nil
+}
+
+func (m *Memory) DeleteUnreferencedBlobs(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var hashes []string
+	for hash, blob := range m.blobs {
+		if blob.RefCount <= 0 {
+			delete(m.blobs, hash)
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes, nil
+}
+
+func (m *Memory) ClaimPresignNonce(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.nonces[nonce]; ok {
+		return false, nil
+	}
+	m.nonces[nonce] = expiresAt
+	return true, nil
+}
+
+// EnsureFolders does nothing, folders are kept in Postgres
+func (m *Memory) EnsureFolders(ctx context.Context, p string) error {
+	return nil
+}
+
+// Atomic runs fn and restores previous state if it fails.
+// Changes made meanwhile outside of fn are lost then too.
+func (m *Memory) Atomic(ctx context.Context, fn func(repo DocumentRepository) error) error {
+	m.txMu.Lock()
+	defer m.txMu.Unlock()
+
+	// Take snapshot
+	m.mu.Lock()
+	documents, versions := maps.Clone(m.documents), maps.Clone(m.versions)
+	blobs, nonces, lastId := maps.Clone(m.blobs), maps.Clone(m.nonces), m.lastId
+	m.mu.Unlock()
+
+	if err := fn(memoryTx{m}); err != nil {
+		m.mu.Lock()
+		m.documents, m.versions = documents, versions
+		m.blobs, m.nonces, m.lastId = blobs, nonces, lastId
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Reports whether document other than id is at path with title
+func (m *Memory) locationTaken(path, title string, id int64) bool {
+	for _, doc := range m.documents {
+		if doc.Id != id && doc.DeletedAt == nil && doc.Path == path && doc.Title == title {
+			return true
+		}
+	}
+	return false
+}
+
+// Removes document with its versions, lock must be held
+func (m *Memory) removeDocument(id int64) {
+	delete(m.documents, id)
+	for vid, v := range m.versions {
+		if v.DocumentId == id {
+			delete(m.versions, vid)
+		}
+	}
+}
+
+// Drops reference on blob, lock must be held
+func (m *Memory) releaseBlob(hash string) {
+	if blob, ok := m.blobs[hash]; ok {
+		blob.RefCount--
+		m.blobs[hash] = blob
+	}
+}
+
+// Reports whether doc matches conditions of filter
+func matchesFilter(doc models.Document, f models.DocumentFilter) bool {
+	switch {
+	case f.AuthorId != nil && doc.AuthorId != *f.AuthorId,
+		f.UploaderId != nil && doc.UploaderId != *f.UploaderId,
+		f.Path != nil && *f.Path != "." && doc.Path != *f.Path && !strings.HasPrefix(doc.Path, *f.Path+"/"),
+		f.Title != nil && !strings.Contains(strings.ToLower(doc.Title), strings.ToLower(*f.Title)),
+		f.Hash != nil && doc.Hash != *f.Hash,
+		f.MinSize != nil && doc.Size < *f.MinSize,
+		f.MaxSize != nil && doc.Size > *f.MaxSize,
+		f.CreatedAfter != nil && parseStoredTime(doc.CreatedAt).Before(*f.CreatedAfter),
+		f.CreatedBefore != nil && parseStoredTime(doc.CreatedAt).After(*f.CreatedBefore),
+		f.ChangedAfter != nil && parseStoredTime(doc.ChangedAt).Before(*f.ChangedAfter),
+		f.ChangedBefore != nil && parseStoredTime(doc.ChangedAt).After(*f.ChangedBefore):
+		return false
+	}
+	return true
+}
+
+// Returns value of sort column of doc as text
+func sortValue(doc models.Document, column string) string {
+	switch column {
+	case "title":
+		return doc.Title
+	case "path":
+		return doc.Path
+	case "created_at":
+		return doc.CreatedAt
+	case "changed_at":
+		return doc.ChangedAt
+	}
+	return ""
+}
+
+// Returns value of numeric sort column of doc
+func sortNumber(doc models.Document, column string) int64 {
+	if column == "size" {
+		return doc.Size
+	}
+	return doc.Id
+}
+
+// Compares value of sort column of doc with cursor value
+func compareSortValue(doc models.Document, column, value string) (int, error) {
+	if column == "id" || column == "size" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(sortNumber(doc, column), n), nil
+	}
+	return strings.Compare(sortValue(doc, column), value), nil
+}
+
+// Parses stored timestamp, zero time is returned on failure
+func parseStoredTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// Current time as it is stored
+func now() string {
+	return time.Now().UTC().Format(timeLayout)
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/docs/models"
+	folderRepository "docshell/internal/v1/folders/repository"
+	"docshell/internal/v1/storage"
+	"time"
+)
+
+// Postgres is DocumentRepository backed by the functions of this package
+type Postgres struct {
+	db *sql.DB
+	// Set for repository passed to Atomic
+	tx *sql.Tx
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Queries run in transaction of Atomic if there is one
+func (p *Postgres) con() storage.Executor {
+	if p.tx != nil {
+		return p.tx
+	}
+	return p.db
+}
+
+func (p *Postgres) GetAllDocuments(ctx context.Context, userId int64, all bool,
+	f models.DocumentFilter) ([]models.Document, string, error) {
+	return GetAllDocuments(ctx, p.con(), userId, all, f)
+}
+
+func (p *Postgres) GetDocumentById(ctx context.Context, id int) (models.Document, error) {
+	return GetDocumentById(ctx, p.con(), id)
+}
+
+func (p *Postgres) GetDocumentForUpdate(ctx context.Context, id int) (models.Document, error) {
+	return GetDocumentForUpdate(ctx, p.con(), id)
+}
+
+func (p *Postgres) GetDocumentByLocation(ctx context.Context, path, title string) (models.Document, error) {
+	return GetDocumentByLocation(ctx, p.con(), path, title)
+}
+
+func (p *Postgres) GetDocumentPermission(ctx context.Context, id int, userId int64) (string, error) {
+	return GetDocumentPermission(ctx, p.con(), id, userId)
+}
+
+func (p *Postgres) GetFolderDocuments(ctx context.Context, userId int64, all bool, path string) ([]models.Document, error) {
+	return GetFolderDocuments(ctx, p.con(), userId, all, path)
+}
+
+func (p *Postgres) GetDocumentPaths(ctx context.Context) ([]string, error) {
+	return GetDocumentPaths(ctx, p.con())
+}
+
+func (p *Postgres) CreateDocument(ctx context.Context, dc models.DocumentCreation) (models.Document, error) {
+	return CreateDocument(ctx, p.con(), dc)
+}
+
+func (p *Postgres) UpdateDocument(ctx context.Context, id int, dc models.DocumentCreation) (models.Document, error) {
+	return UpdateDocument(ctx, p.con(), id, dc)
+}
+
+func (p *Postgres) RemoveDocument(ctx context.Context, id int64) error {
+	return RemoveDocument(ctx, p.con(), id)
+}
+
+func (p *Postgres) GetVersions(ctx context.Context, id int) ([]models.DocumentVersion, error) {
+	return GetVersions(ctx, p.con(), id)
+}
+
+func (p *Postgres) GetVersion(ctx context.Context, id int, version int) (models.DocumentVersion, error) {
+	return GetVersion(ctx, p.con(), id, version)
+}
+
+func (p *Postgres) CreateVersion(ctx context.Context, doc models.Document) (models.DocumentVersion, error) {
+	return CreateVersion(ctx, p.con(), doc)
+}
+
+func (p *Postgres) GetDeletedDocuments(ctx context.Context, userId int64, all bool) ([]models.Document, error) {
+	return GetDeletedDocuments(ctx, p.con(), userId, all)
+}
+
+func (p *Postgres) GetExpiredDocuments(ctx context.Context, before time.Time) ([]models.Document, error) {
+	return GetExpiredDocuments(ctx, p.con(), before)
+}
+
+func (p *Postgres) TrashDocument(ctx context.Context, id int) (models.Document, error) {
+	return TrashDocument(ctx, p.con(), id)
+}
+
+func (p *Postgres) RestoreDocument(ctx context.Context, id int) (models.Document, error) {
+	return RestoreDocument(ctx, p.con(), id)
+}
+
+func (p *Postgres) PurgeDocument(ctx context.Context, id int64) error {
+	if p.tx != nil {
+		return purgeDocument(ctx, p.tx, id)
+	}
+	return PurgeDocument(ctx, p.db, id)
+}
+
+func (p *Postgres) AcquireBlob(ctx context.Context, hash string, size int64) (models.Blob, error) {
+	return AcquireBlob(ctx, p.con(), hash, size)
+}
+
+func (p *Postgres) ReleaseBlob(ctx context.Context, hash string) error {
+	return ReleaseBlob(ctx, p.con(), hash)
+}
+
+func (p *Postgres) GetBlob(ctx context.Context, hash string) (models.Blob, error) {
+	return GetBlob(ctx, p.con(), hash)
+}
+
+func (p *Postgres) DeleteUnreferencedBlobs(ctx context.Context) ([]string, error) {
+	return DeleteUnreferencedBlobs(ctx, p.con())
+}
+
+func (p *Postgres) ClaimPresignNonce(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	return ClaimPresignNonce(ctx, p.con(), nonce, expiresAt)
+}
+
+func (p *Postgres) EnsureFolders(ctx context.Context, path string) error {
+	return folderRepository.EnsureFolders(ctx, p.con(), path)
+}
+
+// Atomic runs fn in transaction, nested calls join the outer one
+func (p *Postgres) Atomic(ctx context.Context, fn func(repo DocumentRepository) error) error {
+	if p.tx != nil {
+		return fn(p)
+	}
+
+	uow, err := storage.Begin(ctx, p.db)
+	if err != nil {
+		return err
+	}
+	defer uow.Rollback()
+
+	if err := fn(&Postgres{db: p.db, tx: uow.Tx}); err != nil {
+		return err
+	}
+	return uow.Commit()
+}
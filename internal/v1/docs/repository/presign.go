@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/storage"
+	"time"
+)
+
+// ClaimPresignNonce marks nonce of pre-signed URL as used,
+// false is returned if it was used before
+func ClaimPresignNonce(ctx context.Context, con storage.Executor, nonce string, expiresAt time.Time) (bool, error) {
+	res, err := con.ExecContext(ctx, claim_presign_nonce, nonce, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteExpiredPresignNonces removes nonces of URLs which can not be used anymore
+func DeleteExpiredPresignNonces(ctx context.Context, con *sql.DB) error {
+	_, err := con.ExecContext(ctx, delete_expired_presign_nonces)
+	return err
+}
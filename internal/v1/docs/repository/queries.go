@@ -1,16 +1,204 @@
 package repository
 
 const (
-	get_all_documents  = "select * from documents;"
-	get_document_by_id = "select * from documents where id = $1;"
-	insert_document    = `
+	document_columns = `
+		id, author_id, uploader_id, title, size, path, hash, content_type,
+		created_at, changed_at, deleted_at
+	`
+
+	// Permissions granted to user $2 on document d, the function is
+	// created by migrations and shared with queries of folders
+	document_grants = "select permission from document_grants($2, d.id, d.path)"
+	// Documents visible to user $2: uploaded by them or granted,
+	// all documents if $1 is true. Filter conditions, order and
+	// limit are appended by GetAllDocuments.
+	get_visible_documents = "select " + document_columns + ` from documents d
+		where d.deleted_at is null
+			and ($1 or d.uploader_id = $2 or exists (` + document_grants + `))
+	`
+	// Visible documents directly in folder $3
+	get_folder_documents = get_visible_documents + `
+			and d.path = $3
+		order by d.title;
+	`
+	get_document_paths = `
+		select distinct path from documents
+			where path <> '.'
+			order by path;
+	`
+	// Permission of user $2 on document $1, uploader may write
+	get_document_permission = `
+		select case
+				when d.uploader_id = $2 or 'write' in (` + document_grants + `) then 'write'
+				when exists (` + document_grants + `) then 'read'
+				else ''
+			end
+			from documents d where d.id = $1;
+	`
+	get_document_by_id = "select " + document_columns +
+		" from documents where id = $1 and deleted_at is null;"
+	get_document_for_update = "select " + document_columns +
+		" from documents where id = $1 and deleted_at is null for update;"
+	get_document_by_location = "select " + document_columns +
+		" from documents where path = $1 and title = $2 and deleted_at is null;"
+	insert_document = `
 		insert into documents (
-			author_id, uploader_id, title, size, path, hash
+			author_id, uploader_id, title, size, path, hash, content_type
 		)
 			values (
-				$1, $2, $3, $4, $5, $6
-				) returning *;
+				$1, $2, $3, $4, $5, $6, $7
+				) returning ` + document_columns + `;
+	`
+	remove_document = "delete from documents where id = $1;"
+	update_document = `
+		update documents
+			set author_id = $2, title = $3, size = $4, path = $5, hash = $6,
+				content_type = $7, changed_at = now()
+			where id = $1 and deleted_at is null
+			returning ` + document_columns + `;
+	`
+
+	// Search
+	// Documents visible to user $2 matching web search query $3 ordered by
+	// rank, at most $4 of them. Snippets are built only for found page,
+	// ts_headline options are $5.
+	search_documents = "select " + document_columns + `, rank,
+			ts_headline('english', title || ' ' || content_text, tsq, $5)
+		from (
+			select d.*, ts_rank(d.search_vector, q) as rank, q as tsq
+				from documents d, websearch_to_tsquery('english', $3) q
+				where d.deleted_at is null
+					and ($1 or d.uploader_id = $2 or exists (` + document_grants + `))
+					and d.search_vector @@ q
+				order by rank desc, d.id
+				limit $4
+		) d
+		order by rank desc, id;
+	`
+	// Documents whose current content has no extracted text, at most $1
+	get_unindexed_documents = `
+		select id, hash, content_type from documents
+			where text_hash is distinct from hash and deleted_at is null
+			order by id
+			limit $1;
+	`
+	// Text is saved only if content $2 was not replaced meanwhile
+	set_document_text = `
+		update documents set content_text = $3, text_hash = $2
+			where id = $1 and hash = $2;
+	`
+
+	// Trash
+	// Deleted documents user $2 may write, all of them if $1 is true
+	get_deleted_documents = "select " + document_columns + ` from documents d
+		where d.deleted_at is not null
+			and ($1 or d.uploader_id = $2 or 'write' in (` + document_grants + `))
+		order by d.deleted_at desc;
+	`
+	get_deleted_document_by_id = "select " + document_columns +
+		" from documents where id = $1 and deleted_at is not null;"
+	get_expired_documents = "select " + document_columns +
+		" from documents where deleted_at < $1;"
+	trash_document = `
+		update documents set deleted_at = now()
+			where id = $1 and deleted_at is null
+			returning ` + document_columns + `;
+	`
+	restore_document = `
+		update documents set deleted_at = null
+			where id = $1 and deleted_at is not null
+			returning ` + document_columns + `;
+	`
+	lock_deleted_document = "select id from documents where id = $1 and deleted_at is not null for update;"
+	delete_document       = "delete from documents where id = $1 and deleted_at is not null;"
+	get_document_hashes   = `
+		select hash from documents where id = $1
+		union all
+		select hash from document_versions where document_id = $1;
+	`
+
+	// Versions
+	version_columns = "id, document_id, version, size, hash, content_type, created_at"
+
+	get_versions = "select " + version_columns +
+		" from document_versions where document_id = $1 order by version desc;"
+	get_version = "select " + version_columns +
+		" from document_versions where document_id = $1 and version = $2;"
+	insert_version = `
+		insert into document_versions (document_id, version, size, hash, content_type)
+			select $1, coalesce(max(version), 0) + 1, $2, $3, $4
+				from document_versions where document_id = $1
+			returning ` + version_columns + `;
+	`
+
+	// Shares
+	share_columns = `
+		id, document_id, created_by, prefix, expires_at, max_downloads,
+		download_count, password_hash, created_at, revoked_at
+	`
+
+	insert_share = `
+		insert into shares (
+			document_id, created_by, prefix, token_hash, expires_at, max_downloads, password_hash
+		)
+			values ($1, $2, $3, $4, $5, $6, $7)
+			returning ` + share_columns + `;
+	`
+	get_document_shares = "select " + share_columns +
+		" from shares where document_id = $1 and revoked_at is null order by id;"
+	// Share which is neither revoked nor expired, it may be used up
+	get_active_share = "select " + share_columns + ` from shares
+		where token_hash = $1 and revoked_at is null
+			and (expires_at is null or expires_at > now());
+	`
+	// Counts download if share allows one more and records it
+	claim_share_download = `
+		with claimed as (
+			update shares set download_count = download_count + 1
+				where id = $1 and revoked_at is null
+					and (expires_at is null or expires_at > now())
+					and (max_downloads is null or download_count < max_downloads)
+				returning id
+		)
+		insert into share_downloads (share_id, remote_addr, user_agent)
+			select id, $2, $3 from claimed
+			returning id;
+	`
+	revoke_share = `
+		update shares set revoked_at = now()
+			where id = $1 and document_id = $2 and revoked_at is null
+			returning ` + share_columns + `;
+	`
+	get_share_downloads = `
+		select d.id, d.share_id, d.remote_addr, d.user_agent, d.downloaded_at
+			from share_downloads d
+			join shares s on s.id = d.share_id
+			where d.share_id = $1 and s.document_id = $2
+			order by d.id;
+	`
+
+	// Pre-signed uploads
+	claim_presign_nonce = `
+		insert into presign_nonces (nonce, expires_at)
+			values ($1, $2)
+			on conflict do nothing;
+	`
+	delete_expired_presign_nonces = "delete from presign_nonces where expires_at < now();"
+
+	// Blobs
+	blob_columns = "hash, size, ref_count, created_at"
+
+	acquire_blob = `
+		insert into blobs (hash, size, ref_count)
+			values ($1, $2, 1)
+			on conflict (hash) do update set ref_count = blobs.ref_count + 1
+			returning ` + blob_columns + `;
 	`
+	release_blob              = "update blobs set ref_count = ref_count - 1 where hash = $1;"
+	get_blob                  = "select " + blob_columns + " from blobs where hash = $1;"
+	delete_unreferenced_blobs = "delete from blobs where ref_count <= 0 returning hash;"
 
-	get_documents_by_ = `select * from documents where $1=$2`
+	// Files saved before blob store
+	get_legacy_files   = "select path, title, hash from legacy_files;"
+	delete_legacy_file = "delete from legacy_files where path = $1 and title = $2;"
 )
@@ -5,26 +5,48 @@ import (
 	"database/sql"
 	"docshell/internal/v1/docs/models"
 	"docshell/internal/v1/storage"
+	"errors"
 )
 
-func GetAllDocuments(ctx context.Context, con *sql.DB) ([]models.Document, error) {
-	// Get all documents
-	rows, err := con.QueryContext(ctx, get_all_documents)
+var ErrNotSaved = errors.New("document was not saved")
+
+// GetAllDocuments returns page of documents visible to user matching
+// filter, all documents are visible if all is true. Cursor of next page
+// is returned with it, empty string on last page.
+func GetAllDocuments(ctx context.Context, con storage.Executor, userId int64, all bool,
+	f models.DocumentFilter) ([]models.Document, string, error) {
+	// Build query, values are always bound as arguments
+	query, args, err := buildDocumentsQuery(userId, all, f)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	defer rows.Close()
 
-	// Build response
-	docs, err := storage.ScanMany(rows, models.ScanDocument)
+	// Get visible documents
+	docs, err := queryDocuments(ctx, con, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return docs, err
+	// One more document than requested is read to find out if page is last
+	if len(docs) <= f.Limit {
+		return docs, "", nil
+	}
+	docs = docs[:f.Limit]
+	return docs, encodeCursor(f, docs[len(docs)-1]), nil
 }
 
-func GetDocumentById(ctx context.Context, con *sql.DB, id int) (models.Document, error) {
+// GetFolderDocuments returns documents visible to user directly
+// in folder with path, all documents are visible if all is true
+func GetFolderDocuments(ctx context.Context, con storage.Executor, userId int64, all bool, path string) ([]models.Document, error) {
+	return queryDocuments(ctx, con, get_folder_documents, all, userId, path)
+}
+
+// GetDocumentPaths returns paths of documents other than the root, in trash too
+func GetDocumentPaths(ctx context.Context, con storage.Executor) ([]string, error) {
+	return queryPaths(ctx, con, get_document_paths)
+}
+
+func GetDocumentById(ctx context.Context, con storage.Executor, id int) (models.Document, error) {
 	// Get document
 	rows, err := con.QueryContext(ctx, get_document_by_id, id)
 	if err != nil {
@@ -40,36 +62,111 @@ func GetDocumentById(ctx context.Context, con *sql.DB, id int) (models.Document,
 	return doc, nil
 }
 
-func CreateDocument(ctx context.Context, con *sql.DB, dc models.DocumentCreation) (models.Document, error) {
+// GetDocumentForUpdate returns document with given id and locks its
+// row until transaction con belongs to ends
+func GetDocumentForUpdate(ctx context.Context, con storage.Executor, id int) (models.Document, error) {
+	return queryDocument(ctx, con, get_document_for_update, id)
+}
+
+// GetDocumentByLocation returns document with given path and title
+func GetDocumentByLocation(ctx context.Context, con storage.Executor, path, title string) (models.Document, error) {
+	return queryDocument(ctx, con, get_document_by_location, path, title)
+}
+
+// CreateDocument inserts document and returns it
+func CreateDocument(ctx context.Context, con storage.Executor, dc models.DocumentCreation) (models.Document, error) {
 	// Insert document and return it
 	rows, err := con.QueryContext(ctx, insert_document,
-		dc.AuthorId, dc.UploaderId, dc.Title, dc.Size, dc.Path, dc.Hash,
+		dc.AuthorId, dc.UploaderId, dc.Title, dc.Size, dc.Path, dc.Hash, dc.ContentType,
 	)
 	if err != nil {
-		return models.Document{}, nil
+		return models.Document{}, err
 	}
 	defer rows.Close()
 
 	// Build response
 	doc, err := storage.ScanSingle(rows, models.ScanDocument)
 	if err != nil {
-		return models.Document{}, nil
+		return models.Document{}, err
+	}
+	// Insert always returns row, its absence is a failure too
+	if doc == (models.Document{}) {
+		return models.Document{}, ErrNotSaved
 	}
 
 	return doc, nil
 }
 
-func GetManyDocementsByRowWithArg(ctx context.Context, con *sql.DB, row string, arg any) ([]models.Document, error) {
-	rows, err := con.QueryContext(ctx, get_documents_by_, row, arg)
+func UpdateDocument(ctx context.Context, con storage.Executor, id int, dc models.DocumentCreation) (models.Document, error) {
+	// Update document and return it
+	rows, err := con.QueryContext(ctx, update_document,
+		id, dc.AuthorId, dc.Title, dc.Size, dc.Path, dc.Hash, dc.ContentType,
+	)
 	if err != nil {
-		return nil, err
+		return models.Document{}, err
 	}
+	defer rows.Close()
 
 	// Build response
-	docs, err := storage.ScanMany(rows, models.ScanDocument)
+	doc, err := storage.ScanSingle(rows, models.ScanDocument)
+	if err != nil {
+		return models.Document{}, err
+	}
+
+	return doc, nil
+}
+
+// RemoveDocument removes document record with its versions
+// permanently, references on blobs are not released
+func RemoveDocument(ctx context.Context, con storage.Executor, id int64) error {
+	_, err := con.ExecContext(ctx, remove_document, id)
+	return err
+}
+
+// GetDocumentPermission returns 'read' or 'write' permission of user
+// on document including deleted one, or empty string if it has none
+func GetDocumentPermission(ctx context.Context, con storage.Executor, id int, userId int64) (string, error) {
+	var permission string
+	err := con.QueryRowContext(ctx, get_document_permission, id, userId).Scan(&permission)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return permission, err
+}
+
+// Runs query returning single document
+func queryDocument(ctx context.Context, con storage.Executor, query string, args ...any) (models.Document, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.Document{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanDocument)
+}
+
+// Runs query returning many documents
+func queryDocuments(ctx context.Context, con storage.Executor, query string, args ...any) ([]models.Document, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanDocument)
+}
+
+// Runs query returning paths
+func queryPaths(ctx context.Context, con storage.Executor, query string, args ...any) ([]string, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return docs, nil
+	return storage.ScanMany(rows, func(rows *sql.Rows) (string, error) {
+		var p string
+		err := rows.Scan(&p)
+		return p, err
+	})
 }
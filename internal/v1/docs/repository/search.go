@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/storage"
+)
+
+// SearchDocuments returns at most limit documents visible to user
+// matching query, all documents are visible if all is true.
+// Snippets are built with ts_headline options.
+func SearchDocuments(ctx context.Context, con *sql.DB, userId int64, all bool,
+	query string, limit int, options string) ([]models.SearchResult, error) {
+	rows, err := con.QueryContext(ctx, search_documents, all, userId, query, limit, options)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanSearchResult)
+}
+
+// GetUnindexedDocuments returns at most limit documents
+// whose current content has no extracted text
+func GetUnindexedDocuments(ctx context.Context, con *sql.DB, limit int) ([]models.UnindexedDocument, error) {
+	rows, err := con.QueryContext(ctx, get_unindexed_documents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanUnindexedDocument)
+}
+
+// SetDocumentText saves text extracted from content with hash,
+// nothing is changed if document content was replaced meanwhile
+func SetDocumentText(ctx context.Context, con *sql.DB, id int64, hash, text string) error {
+	_, err := con.ExecContext(ctx, set_document_text, id, hash, text)
+	return err
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/storage"
+	"time"
+)
+
+// CreateShare saves share of document made by user with hashed token
+// and password, nil limits and password are not applied
+func CreateShare(ctx context.Context, con *sql.DB, id int, userId int64, prefix, tokenHash string,
+	expiresAt *time.Time, maxDownloads *int, passwordHash *string) (models.Share, error) {
+	return queryShare(ctx, con, insert_share, id, userId, prefix,
+		tokenHash, expiresAt, maxDownloads, passwordHash)
+}
+
+// GetDocumentShares returns not revoked shares of document
+func GetDocumentShares(ctx context.Context, con *sql.DB, id int) ([]models.Share, error) {
+	rows, err := con.QueryContext(ctx, get_document_shares, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanShare)
+}
+
+// GetActiveShare returns share with given token hash
+// if it is neither revoked nor expired
+func GetActiveShare(ctx context.Context, con *sql.DB, tokenHash string) (models.Share, error) {
+	return queryShare(ctx, con, get_active_share, tokenHash)
+}
+
+// ClaimShareDownload counts and records download through share,
+// false is returned if share does not allow more downloads
+func ClaimShareDownload(ctx context.Context, con *sql.DB, id int64, remoteAddr, userAgent string) (bool, error) {
+	var downloadId int64
+	err := con.QueryRowContext(ctx, claim_share_download, id, remoteAddr, userAgent).Scan(&downloadId)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RevokeShare revokes share of document
+func RevokeShare(ctx context.Context, con *sql.DB, id int64, documentId int) (models.Share, error) {
+	return queryShare(ctx, con, revoke_share, id, documentId)
+}
+
+// GetShareDownloads returns downloads made through share of document
+func GetShareDownloads(ctx context.Context, con *sql.DB, id int64, documentId int) ([]models.ShareDownload, error) {
+	rows, err := con.QueryContext(ctx, get_share_downloads, id, documentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanShareDownload)
+}
+
+// Runs query returning single share
+func queryShare(ctx context.Context, con *sql.DB, query string, args ...any) (models.Share, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.Share{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanShare)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/storage"
+	"time"
+)
+
+// GetDeletedDocuments returns deleted documents user may write,
+// all of them if all is true
+func GetDeletedDocuments(ctx context.Context, con storage.Executor, userId int64, all bool) ([]models.Document, error) {
+	return queryDocuments(ctx, con, get_deleted_documents, all, userId)
+}
+
+func GetDeletedDocumentById(ctx context.Context, con storage.Executor, id int) (models.Document, error) {
+	return queryDocument(ctx, con, get_deleted_document_by_id, id)
+}
+
+// GetExpiredDocuments returns documents deleted before given time
+func GetExpiredDocuments(ctx context.Context, con storage.Executor, before time.Time) ([]models.Document, error) {
+	return queryDocuments(ctx, con, get_expired_documents, before)
+}
+
+// TrashDocument marks document as deleted
+func TrashDocument(ctx context.Context, con storage.Executor, id int) (models.Document, error) {
+	return queryDocument(ctx, con, trash_document, id)
+}
+
+// RestoreDocument unmarks deleted document
+func RestoreDocument(ctx context.Context, con storage.Executor, id int) (models.Document, error) {
+	return queryDocument(ctx, con, restore_document, id)
+}
+
+// PurgeDocument removes deleted document record with its versions permanently
+// and releases blobs referenced by them
+func PurgeDocument(ctx context.Context, con *sql.DB, id int64) error {
+	tx, err := con.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := purgeDocument(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Purges document inside transaction tx
+func purgeDocument(ctx context.Context, tx storage.Executor, id int64) error {
+	// Lock document, it may be restored meanwhile
+	var locked int64
+	err := tx.QueryRowContext(ctx, lock_deleted_document, id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Collect hashes of document and its versions
+	rows, err := tx.QueryContext(ctx, get_document_hashes, id)
+	if err != nil {
+		return err
+	}
+	hashes, err := storage.ScanMany(rows, scanHash)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	// Release every reference
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, release_blob, hash); err != nil {
+			return err
+		}
+	}
+
+	// Remove record, versions are removed by cascade
+	_, err = tx.ExecContext(ctx, delete_document, id)
+	return err
+}
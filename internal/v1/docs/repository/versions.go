@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/storage"
+)
+
+func GetVersions(ctx context.Context, con storage.Executor, id int) ([]models.DocumentVersion, error) {
+	rows, err := con.QueryContext(ctx, get_versions, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanVersion)
+}
+
+func GetVersion(ctx context.Context, con storage.Executor, id int, version int) (models.DocumentVersion, error) {
+	rows, err := con.QueryContext(ctx, get_version, id, version)
+	if err != nil {
+		return models.DocumentVersion{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanVersion)
+}
+
+// CreateVersion saves current content of document as next version
+func CreateVersion(ctx context.Context, con storage.Executor, doc models.Document) (models.DocumentVersion, error) {
+	rows, err := con.QueryContext(ctx, insert_version, doc.Id, doc.Size, doc.Hash, doc.ContentType)
+	if err != nil {
+		return models.DocumentVersion{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanVersion)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/utils"
+	"docshell/internal/v1/volume"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+)
+
+// Staged files older than this are left by uploads
+// interrupted before their content was stored
+const staleUploadAge = 24 * time.Hour
+
+// storeBlob takes reference on blob with staged file content
+// and moves the file into blob store if it is not stored yet
+func storeBlob(ctx context.Context, repo repository.DocumentRepository, file *utils.StagedFile) (models.Blob, error) {
+	// Reference is taken first, so collector can not remove the blob
+	blob, err := repo.AcquireBlob(ctx, file.Hash, file.Size)
+	if err != nil {
+		return models.Blob{}, err
+	}
+
+	if err := utils.CommitBlob(ctx, file); err != nil {
+		releaseBlob(ctx, repo, file.Hash)
+		return models.Blob{}, err
+	}
+	return blob, nil
+}
+
+// releaseBlob drops reference on blob, blob itself
+// is removed later by CollectGarbage
+func releaseBlob(ctx context.Context, repo repository.DocumentRepository, hash string) {
+	if err := repo.ReleaseBlob(ctx, hash); err != nil {
+		log.Println(err)
+	}
+}
+
+// CollectGarbage removes blobs whose last reference was dropped,
+// file of blob is removed after its record is deleted
+func (s *Service) CollectGarbage(ctx context.Context) error {
+	hashes, err := s.documents.DeleteUnreferencedBlobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, hash := range hashes {
+		// File left behind takes space only, so others are removed anyway
+		if err := s.removeBlob(ctx, hash); err != nil {
+			log.Printf("Blob %v could not be removed, because of %v", hash, err)
+			continue
+		}
+		n++
+	}
+	if n > 0 {
+		log.Printf("Removed %d unreferenced blobs", n)
+	}
+	return nil
+}
+
+// removeBlob removes file of blob whose record was deleted. Upload of the
+// same content may acquire blob again meanwhile and find the file, so the
+// file is moved aside first and put back if blob has a record again.
+func (s *Service) removeBlob(ctx context.Context, hash string) error {
+	key := volume.GetBlobKey(hash)
+	aside := volume.NewStagingKey()
+	if err := s.storage.Move(ctx, key, aside); err != nil {
+		if errors.Is(err, volume.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	blob, err := s.documents.GetBlob(ctx, hash)
+	if err != nil || blob != (models.Blob{}) {
+		if err := s.storage.Move(ctx, aside, key); err != nil {
+			return err
+		}
+		return err
+	}
+	return s.storage.Delete(ctx, aside)
+}
+
+// RemoveStaleUploads removes staged files of uploads which never finished
+func (s *Service) RemoveStaleUploads(ctx context.Context) error {
+	files, err := volume.ListStagedFiles(ctx, s.storage)
+	if err != nil {
+		return err
+	}
+
+	before := time.Now().Add(-staleUploadAge)
+	n := 0
+	for _, f := range files {
+		if f.ModTime.After(before) {
+			continue
+		}
+		err := s.storage.Delete(ctx, f.Key)
+		if err != nil && !errors.Is(err, volume.ErrNotExist) {
+			return err
+		}
+		n++
+	}
+	if n > 0 {
+		log.Printf("Removed %d stale uploads", n)
+	}
+	return nil
+}
+
+// MoveLegacyFiles moves files of documents saved under their
+// path and title before blob store existed into blob store
+func (s *Service) MoveLegacyFiles(ctx context.Context) error {
+	files, err := repository.GetLegacyFiles(ctx, s.db)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if err := s.moveLegacyFile(ctx, f); err != nil {
+			return err
+		}
+		if err := repository.DeleteLegacyFile(ctx, s.db, f); err != nil {
+			return err
+		}
+	}
+	if len(files) > 0 {
+		log.Printf("Moved %d files into blob store", len(files))
+	}
+	return nil
+}
+
+// Moves legacy file into blob store unless blob with its content is
+// stored already, file moved before is not looked for again
+func (s *Service) moveLegacyFile(ctx context.Context, f models.LegacyFile) error {
+	key := path.Join(utils.CleanPath(f.Path), f.Title)
+	blobKey := volume.GetBlobKey(f.Hash)
+	_, err := s.storage.Stat(ctx, blobKey)
+	if err == nil {
+		err := s.storage.Delete(ctx, key)
+		if err != nil && !errors.Is(err, volume.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if !errors.Is(err, volume.ErrNotExist) {
+		return err
+	}
+
+	err = s.storage.Move(ctx, key, blobKey)
+	if errors.Is(err, volume.ErrNotExist) {
+		log.Printf("File %v of document saved before blob store is missing", key)
+		return nil
+	}
+	return err
+}
+
+// Types browsers may display inline without running active content
+var inlineTypes = map[string]bool{
+	"application/pdf": true,
+	"text/plain":      true,
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/avif":      true,
+	"audio/mpeg":      true,
+	"audio/ogg":       true,
+	"audio/wav":       true,
+	"video/mp4":       true,
+	"video/webm":      true,
+	"video/ogg":       true,
+}
+
+// serveBlob sends blob content of contentType named title. Content is sent
+// as attachment unless '?disposition=inline' is requested for safe type.
+// Range and conditional requests are handled, ETag is content hash.
+func (s *Service) serveBlob(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	title, hash, contentType string, modTime time.Time) {
+	// Read query param
+	disposition, ok := readDisposition(w, r)
+	if !ok {
+		return
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// Active content (HTML, SVG, ...) is never rendered inline
+	base, _, _ := mime.ParseMediaType(contentType)
+	if !inlineTypes[base] {
+		disposition = "attachment"
+	}
+
+	// Open file
+	file, err := utils.OpenBlob(ctx, s.storage, hash)
+	if err != nil {
+		log.Println(err)
+		msg := fmt.Sprintf("Could not open file %v", title)
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	defer file.Close()
+
+	// Set headers
+	w.Header().Set("Content-Disposition", utils.ContentDisposition(disposition, title))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Blob content never changes, so its hash is a strong validator
+	w.Header().Set("ETag", `"`+hash+`"`)
+
+	// Serves ranges, answers If-None-Match, If-Modified-Since
+	// and If-Range using ETag and modTime
+	http.ServeContent(w, r, title, modTime, file)
+}
+
+// Reads 'disposition' query value, attachment by default.
+// Sends error response if it is incorrect.
+func readDisposition(w http.ResponseWriter, r *http.Request) (string, bool) {
+	disposition := r.URL.Query().Get("disposition")
+	switch disposition {
+	case "":
+		return "attachment", true
+	case "attachment", "inline":
+		return disposition, true
+	default:
+		msg := fmt.Sprintf("Query value 'disposition=%v' incorrect", disposition)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return "", false
+	}
+}
+
+// Parses database timestamp, zero time is returned on failure
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
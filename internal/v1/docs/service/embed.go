@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/docs/models"
+	usersRepository "docshell/internal/v1/users/repository"
+)
+
+// embedUsers fills author and uploader names of documents
+func embedUsers(ctx context.Context, con *sql.DB, docs []models.Document) error {
+	// Collect distinct user ids
+	seen := make(map[int64]bool)
+	var ids []int64
+	for _, doc := range docs {
+		for _, id := range []int64{doc.AuthorId, doc.UploaderId} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// Get users at once
+	users, err := usersRepository.GetUsersByIds(ctx, con, ids)
+	if err != nil {
+		return err
+	}
+	refs := make(map[int64]*models.UserRef, len(users))
+	for _, user := range users {
+		refs[user.Id] = &models.UserRef{Id: user.Id, Name: user.Name}
+	}
+
+	for i := range docs {
+		docs[i].Author = refs[docs[i].AuthorId]
+		docs[i].Uploader = refs[docs[i].UploaderId]
+	}
+	return nil
+}
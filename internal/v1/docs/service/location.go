@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MoveDocument changes path and title of document keeping its id and
+// versions. Content is stored by hash, so only the record changes.
+func (s *Service) MoveDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, loc models.DocumentLocation) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check user may change document
+	if !policy.AuthorizeDocument(ctx, w, repo, id, aclModels.PermissionWrite) {
+		return
+	}
+
+	s.updateDocument(ctx, w, id, nil, "", models.DocumentUpdate{
+		Path:  loc.Path,
+		Title: loc.Title,
+	})
+}
+
+// CopyDocument creates document with content of another one at new
+// location. Copy shares the blob, its history starts anew.
+func (s *Service) CopyDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, loc models.DocumentLocation) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check document is visible to user
+	if !policy.AuthorizeDocument(ctx, w, repo, id, aclModels.PermissionRead) {
+		return
+	}
+
+	// Get source document
+	src, err := repo.GetDocumentById(ctx, id)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if src == (models.Document{}) {
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	// Copy is uploaded by the authenticated user
+	user, _ := auth.UserFromContext(ctx)
+	dc := models.DocumentCreation{
+		AuthorId:    src.AuthorId,
+		UploaderId:  user.Id,
+		Title:       src.Title,
+		Size:        src.Size,
+		Path:        src.Path,
+		Hash:        src.Hash,
+		ContentType: src.ContentType,
+	}
+	if loc.Title != nil {
+		dc.Title = *loc.Title
+	}
+	if loc.Path != nil {
+		dc.Path = utils.CleanPath(*loc.Path)
+	}
+	// Title is used as file name on download
+	if !validTitle(dc.Title) {
+		msg := fmt.Sprintf("Title '%v' incorrect", dc.Title)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Check user may write into folder of copy
+	if !policy.AuthorizeFolder(ctx, w, s.db, dc.Path, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Copy is saved with its own reference on content
+	// and folders of its path in one transaction
+	doc, err := copyDocument(ctx, repo, dc)
+	if err != nil {
+		sendDocumentError(w, err, "Database error: could not copy document")
+		return
+	}
+	// Extract text of copy for search
+	s.requestIndexing()
+
+	utils.SendJSONResponse(w, models.ResponseSingleDocument{
+		StatusCode: http.StatusOK,
+		Document:   doc,
+	})
+}
+
+// Saves document with content of already stored blob
+func copyDocument(ctx context.Context, repo repository.DocumentRepository,
+	dc models.DocumentCreation) (models.Document, error) {
+	var doc models.Document
+	err := repo.Atomic(ctx, func(repo repository.DocumentRepository) error {
+		if _, err := repo.AcquireBlob(ctx, dc.Hash, dc.Size); err != nil {
+			return err
+		}
+		if err := repo.EnsureFolders(ctx, dc.Path); err != nil {
+			return err
+		}
+		var err error
+		doc, err = repo.CreateDocument(ctx, dc)
+		return err
+	})
+	return doc, err
+}
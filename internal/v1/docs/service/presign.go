@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Pre-signed upload URL was used before
+var errUploadClaimed = errors.New("pre-signed URL was already used")
+
+// Paths served to pre-signed requests
+const (
+	PresignedDownloadPath = "/presigned/docs/id/%d/content"
+	PresignedUploadPath   = "/presigned/docs/upload"
+)
+
+// PresignDownload sends URL downloading document without
+// authentication until ttl passes
+func (s *Service) PresignDownload(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, ttl time.Duration) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check document is visible to user
+	if !policy.AuthorizeDocument(ctx, w, repo, id, aclModels.PermissionRead) {
+		return
+	}
+
+	// Check document exists
+	doc, err := repo.GetDocumentById(ctx, id)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if doc == (models.Document{}) {
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	// Sign URL for the user, its permissions are checked again on download
+	user, _ := auth.UserFromContext(ctx)
+	expires := time.Now().Add(ttl)
+	path := fmt.Sprintf(PresignedDownloadPath, id)
+	sendPresignedUrl(w, http.MethodGet, s.signer.PresignURL(http.MethodGet, path, nil, user.Id, expires), expires)
+}
+
+// PresignUpload sends URL accepting single PUT of file
+// into fixed path and title until ttl passes
+func (s *Service) PresignUpload(ctx context.Context, w http.ResponseWriter, r *http.Request, pu models.PresignUpload, ttl time.Duration) {
+	// Validate fields
+	pu.Path = utils.CleanPath(pu.Path)
+	if !validTitle(pu.Title) {
+		msg := fmt.Sprintf("Title '%v' incorrect", pu.Title)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Permissions are checked again on upload, fail early if they lack
+	if !policy.AuthorizeFolder(ctx, w, s.db, pu.Path, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Nonce makes URL usable once
+	nonce, err := auth.NewNonce()
+	if err != nil {
+		log.Println(err)
+		msg := "URL could not be signed"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Author defaults to the user
+	user, _ := auth.UserFromContext(ctx)
+	if pu.AuthorId == 0 {
+		pu.AuthorId = user.Id
+	}
+
+	query := url.Values{
+		"author_id": {strconv.FormatInt(pu.AuthorId, 10)},
+		"path":      {pu.Path},
+		"title":     {pu.Title},
+		"nonce":     {nonce},
+	}
+	expires := time.Now().Add(ttl)
+	sendPresignedUrl(w, http.MethodPut, s.signer.PresignURL(http.MethodPut, PresignedUploadPath, query, user.Id, expires), expires)
+}
+
+// UploadPresigned creates document from file uploaded to pre-signed URL
+// with nonce. Nonce is claimed with saving of document, so URL stays
+// usable if upload fails.
+func (s *Service) UploadPresigned(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	file *utils.StagedFile, dc models.DocumentCreation, nonce string, expires time.Time) {
+	s.saveDocument(ctx, w, file, dc, func(repo repository.DocumentRepository) error {
+		ok, err := repo.ClaimPresignNonce(ctx, nonce, expires)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errUploadClaimed
+		}
+		return nil
+	})
+}
+
+// Sends pre-signed URL response
+func sendPresignedUrl(w http.ResponseWriter, method, signed string, expires time.Time) {
+	w.Header().Set("Cache-Control", "no-store")
+	utils.SendJSONResponse(w, models.ResponsePresignedUrl{
+		StatusCode: http.StatusOK,
+		Method:     method,
+		Url:        signed,
+		ExpiresAt:  expires.UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/extract"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// How often documents missed by upload notifications are indexed
+	indexInterval = time.Minute
+	// Number of documents extracted between database reads
+	indexBatchSize = 20
+)
+
+// Options of snippets, matches are marked with characters
+// which can not occur in text and replaced after escaping
+var headlineOptions = fmt.Sprintf("MaxFragments=2, MaxWords=20, MinWords=5, "+
+	"FragmentDelimiter=\" … \", StartSel=%s, StopSel=%s", extract.MarkStart, extract.MarkStop)
+
+func (s *Service) SearchDocuments(ctx context.Context, w http.ResponseWriter, r *http.Request, query string, limit int) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Search documents visible to user
+	user, _ := auth.UserFromContext(ctx)
+	results, err := repository.SearchDocuments(ctx, con, user.Id,
+		policy.Can(user, policy.AccessAllDocuments), query, limit, headlineOptions)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not search docs"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Snippets are sent as HTML with marked matches
+	for i := range results {
+		results[i].Snippet = strings.NewReplacer(
+			extract.MarkStart, "<mark>", extract.MarkStop, "</mark>",
+		).Replace(html.EscapeString(results[i].Snippet))
+	}
+
+	res := models.ResponseSearchResults{
+		StatusCode: http.StatusOK,
+		Results:    make([]models.SearchResult, len(results)),
+	}
+	copy(res.Results, results)
+	utils.SendJSONResponse(w, res)
+}
+
+// requestIndexing asks indexer to extract text of new content
+func (s *Service) requestIndexing() {
+	select {
+	case s.indexRequests <- struct{}{}:
+	default: // Already requested
+	}
+}
+
+// RunIndexer extracts text of uploaded content for search when
+// requested and periodically until ctx is done
+func (s *Service) RunIndexer(ctx context.Context) {
+	ticker := time.NewTicker(indexInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.IndexDocuments(ctx); err != nil {
+			log.Printf("Text extraction fault with %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.indexRequests:
+		}
+	}
+}
+
+// IndexDocuments extracts text of all documents
+// whose current content was not indexed yet
+func (s *Service) IndexDocuments(ctx context.Context) error {
+	// Get db connection
+	con := s.db
+
+	for {
+		docs, err := repository.GetUnindexedDocuments(ctx, con, indexBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		for _, doc := range docs {
+			// Content which can not be read is saved without text,
+			// so it is not tried again until replaced
+			text, err := s.extractText(ctx, doc)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil && !errors.Is(err, extract.ErrUnsupported) {
+				log.Printf("Text of document %d could not be extracted, because of %v", doc.Id, err)
+			}
+			if err := repository.SetDocumentText(ctx, con, doc.Id, doc.Hash, text); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Returns text of document content. Panic of extractor on malformed
+// content is returned as error, so it does not stop the process.
+func (s *Service) extractText(ctx context.Context, doc models.UnindexedDocument) (text string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			text, err = "", fmt.Errorf("extractor panicked: %v", p)
+		}
+	}()
+
+	file, err := utils.OpenBlob(ctx, s.storage, doc.Hash)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return extract.Text(file, doc.ContentType)
+}
@@ -1,42 +1,91 @@
 package service
 
 import (
-	"bytes"
 	"context"
+	"database/sql"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
 	"docshell/internal/v1/docs/models"
 	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/policy"
 	"docshell/internal/v1/utils"
 	"docshell/internal/v1/volume"
 	"errors"
 	"fmt"
-	"io"
 	"log"
-	"mime/multipart"
-	"os"
-	"path/filepath"
-	"sync"
+	"path"
+	"strings"
 
 	"docshell/internal/v1/storage"
 	"net/http"
 	"time"
 )
 
-func GetAllDocuments(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+// Service manages documents with their content, versions and shares
+type Service struct {
+	// Postgres database of users, shares, upload nonces and search index
+	db *sql.DB
+	// Files of documents
+	storage volume.Storage
+	// Document records
+	documents repository.DocumentRepository
+	// Signs pre-signed URLs
+	signer *auth.Signer
+	// How long deleted documents are kept in trash
+	retention time.Duration
+	// Wakes indexer up, holds at most one pending request
+	indexRequests chan struct{}
+}
+
+// New returns service keeping records in documents, their content in
+// store and shares, upload nonces and search index in db
+func New(db *sql.DB, store volume.Storage, documents repository.DocumentRepository,
+	signer *auth.Signer, retention time.Duration) *Service {
+	// Missing repository is a wiring bug, there is no default one
+	if documents == nil {
+		panic("docs service: documents repository is required")
+	}
+	return &Service{
+		db:            db,
+		storage:       store,
+		documents:     documents,
+		signer:        signer,
+		retention:     retention,
+		indexRequests: make(chan struct{}, 1),
+	}
+}
+
+func (s *Service) GetAllDocuments(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	embed bool, filter models.DocumentFilter) {
 	// Set timeout context
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	// Get db connection
-	con := storage.GetConnection()
-
-	// Get all documents from repository
-	docs, err := repository.GetAllDocuments(ctx, con)
+	// Get documents visible to user from repository
+	user, _ := auth.UserFromContext(ctx)
+	docs, next, err := s.documents.GetAllDocuments(ctx, user.Id,
+		policy.Can(user, policy.AccessAllDocuments), filter)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		msg := "Query value 'cursor' incorrect"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
 	if err != nil {
+		log.Println(err)
 		msg := "Database error: could not read docs"
 		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
 		return
 	}
 
+	// Embed author and uploader names
+	if embed {
+		if err := embedUsers(ctx, s.db, docs); err != nil {
+			msg := "Database error: could not read users"
+			utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+			return
+		}
+	}
+
 	select {
 	case <-ctx.Done(): // Context exceeded
 		if ctx.Err() == context.DeadlineExceeded {
@@ -51,6 +100,7 @@ func GetAllDocuments(ctx context.Context, w http.ResponseWriter, r *http.Request
 		res := models.ResponseMultipleDocuments{
 			StatusCode: http.StatusOK,
 			Documents:  make([]models.Document, len(docs)),
+			NextCursor: next,
 		}
 		// Copy documents
 		copy(res.Documents, docs)
@@ -58,16 +108,21 @@ func GetAllDocuments(ctx context.Context, w http.ResponseWriter, r *http.Request
 	}
 }
 
-func GetDocumentById(ctx context.Context, w http.ResponseWriter, r *http.Request, id int) {
+func (s *Service) GetDocumentById(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, embed bool) {
 	// Set timeout context
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	// Get db connection
-	con := storage.GetConnection()
+	// Get repository
+	repo := s.documents
+
+	// Check document is visible to user
+	if !policy.AuthorizeDocument(ctx, w, repo, id, aclModels.PermissionRead) {
+		return
+	}
 
 	// Get document
-	doc, err := repository.GetDocumentById(ctx, con, id)
+	doc, err := repo.GetDocumentById(ctx, id)
 	if err != nil {
 		msg := "Internal server error"
 		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
@@ -80,6 +135,17 @@ func GetDocumentById(ctx context.Context, w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Embed author and uploader names
+	if embed {
+		docs := []models.Document{doc}
+		if err := embedUsers(ctx, s.db, docs); err != nil {
+			msg := "Database error: could not read users"
+			utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+			return
+		}
+		doc = docs[0]
+	}
+
 	select {
 	case <-ctx.Done(): // Context exceed
 		msg := "Internal context exceed"
@@ -92,123 +158,393 @@ func GetDocumentById(ctx context.Context, w http.ResponseWriter, r *http.Request
 	}
 }
 
-func CreateDocument(ctx context.Context, w http.ResponseWriter, r *http.Request,
-	file io.Reader, header *multipart.FileHeader, dc models.DocumentCreation) {
-	// Read all flie to memory
-	fileBytes, err := io.ReadAll(file)
+func (s *Service) CreateDocument(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	file *utils.StagedFile, dc models.DocumentCreation) {
+	s.saveDocument(ctx, w, file, dc, nil)
+}
+
+// saveDocument creates document from staged file, claim runs in the
+// transaction saving document and keeps it from being saved if it fails
+func (s *Service) saveDocument(ctx context.Context, w http.ResponseWriter, file *utils.StagedFile,
+	dc models.DocumentCreation, claim func(repo repository.DocumentRepository) error) {
+	// Uploader is the authenticated user
+	user, _ := auth.UserFromContext(ctx)
+	dc.UploaderId = user.Id
+
+	// Fill DocumentCreation fields from staged file
+	dc.Title = file.Filename
+	dc.Size = file.Size
+	dc.Path = utils.CleanPath(dc.Path)
+	dc.Hash = file.Hash
+	dc.ContentType = file.ContentType
+
+	// Title is used as file name on download
+	if !validTitle(dc.Title) {
+		msg := fmt.Sprintf("Title '%v' incorrect", dc.Title)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Check user may write into folder
+	if !policy.AuthorizeFolder(ctx, w, s.db, dc.Path, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Save document, content is moved into blob store after the record
+	doc, err := createDocument(ctx, s.documents, file, dc, claim)
 	if err != nil {
-		msg := "Document can not be read"
-		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		sendDocumentError(w, err, "Database error: could not save document")
 		return
 	}
+	// Extract text of content for search
+	s.requestIndexing()
+
+	// Sends Response
+	utils.SendJSONResponse(w, models.ResponseSingleDocument{
+		StatusCode: http.StatusOK,
+		Document:   doc,
+	})
+}
+
+// createDocument saves document record, reference on its content and
+// folders of its path atomically, after claim if it is not nil. Staged
+// file is moved into blob store after that, document is removed again
+// if it fails.
+func createDocument(ctx context.Context, repo repository.DocumentRepository, file *utils.StagedFile,
+	dc models.DocumentCreation, claim func(repo repository.DocumentRepository) error) (models.Document, error) {
+	var doc models.Document
+	err := repo.Atomic(ctx, func(repo repository.DocumentRepository) error {
+		if claim != nil {
+			if err := claim(repo); err != nil {
+				return err
+			}
+		}
+		// Reference keeps collector from removing blob with same content
+		if _, err := repo.AcquireBlob(ctx, dc.Hash, dc.Size); err != nil {
+			return err
+		}
+		if err := repo.EnsureFolders(ctx, dc.Path); err != nil {
+			return err
+		}
+		var err error
+		doc, err = repo.CreateDocument(ctx, dc)
+		return err
+	})
+	if err != nil {
+		return models.Document{}, err
+	}
+
+	if err := utils.CommitBlob(ctx, file); err != nil {
+		removeDocument(ctx, repo, doc)
+		return models.Document{}, err
+	}
+	return doc, nil
+}
+
+// removeDocument compensates creation of document whose
+// content could not be stored, its reference is released
+func removeDocument(ctx context.Context, repo repository.DocumentRepository, doc models.Document) {
+	// Request may be cancelled already
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	err := repo.Atomic(ctx, func(repo repository.DocumentRepository) error {
+		if err := repo.RemoveDocument(ctx, doc.Id); err != nil {
+			return err
+		}
+		return repo.ReleaseBlob(ctx, doc.Hash)
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *Service) UpdateDocument(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	id int, file *utils.StagedFile, du models.DocumentUpdate) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check user may change document
+	if !policy.AuthorizeDocument(ctx, w, repo, id, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Store new content
+	var content *models.Blob
+	var contentType string
+	if file != nil {
+		blob, err := storeBlob(ctx, repo, file)
+		if err != nil {
+			log.Println(err)
+			msg := "Document could not be saved"
+			utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+			return
+		}
+		content = &blob
+		contentType = file.ContentType
+
+		// New content is uploaded by the authenticated user
+		user, _ := auth.UserFromContext(ctx)
+		du.UploaderId = &user.Id
+	}
+
+	s.updateDocument(ctx, w, id, content, contentType, du)
+}
+
+// Document was deleted while it was being updated
+var errDocumentNotFound = errors.New("document not found")
+
+// updateDocument applies metadata changes and new content of contentType
+// to document. Reference on content is taken over by document
+// or released on failure.
+func (s *Service) updateDocument(ctx context.Context, w http.ResponseWriter,
+	id int, content *models.Blob, contentType string, du models.DocumentUpdate) {
+	// Get repository
+	repo := s.documents
+
+	// Release content if document does not take it
+	undo := func() {
+		if content != nil {
+			releaseBlob(ctx, repo, content.Hash)
+		}
+	}
 
-	// Fill DocumentCreation fields
-	dc.Title = header.Filename
-	dc.Size = header.Size
-	dc.Path = filepath.Clean(dc.Path)
-	dc.Hash, err = utils.GenerateHash(fileBytes)
+	// Get current document
+	old, err := repo.GetDocumentById(ctx, id)
 	if err != nil {
-		msg := "Hash generation fault"
+		undo()
+		msg := "Internal server error"
 		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
 		return
 	}
+	if old == (models.Document{}) {
+		undo()
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
 
-	// Set context to cancel if error occured
-	ctx, cancel := context.WithCancelCause(ctx)
-	defer cancel(nil)
-	// Set error chan to save goroutines error
-	errChan := make(chan error, 2)
-	// Set wait group to synchronize goroutines
-	var wg sync.WaitGroup
-	wg.Add(2)
+	// Title is used as file name on download
+	dc := applyUpdate(old, du)
+	if !validTitle(dc.Title) {
+		undo()
+		msg := fmt.Sprintf("Title '%v' incorrect", dc.Title)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
 
-	// Get db connection
-	con := storage.GetConnection()
+	// Check user may write into new folder
+	if dc.Path != old.Path {
+		if !policy.AuthorizeFolder(ctx, w, s.db, dc.Path, aclModels.PermissionWrite) {
+			undo()
+			return
+		}
+	}
 
-	// Create document that will send user
+	// Document is locked while it is versioned and updated,
+	// so concurrent updates keep every previous content
 	var doc models.Document
+	replaced := false
+	err = repo.Atomic(ctx, func(repo repository.DocumentRepository) error {
+		old, err := repo.GetDocumentForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if old == (models.Document{}) {
+			return errDocumentNotFound
+		}
+
+		dc := applyUpdate(old, du)
+		if dc.Path != old.Path {
+			if err := repo.EnsureFolders(ctx, dc.Path); err != nil {
+				return err
+			}
+		}
+
+		// Same content does not need new version
+		replaced = content != nil && content.Hash != old.Hash
+		if replaced {
+			dc.Size = content.Size
+			dc.Hash = content.Hash
+			dc.ContentType = contentType
 
-	// Save document record to database
-	go func() {
-		defer wg.Done()
-		if doc, err = repository.CreateDocument(ctx, con, dc); err != nil {
-			errChan <- err
-			cancel(err)
-		}
-
-		// May be caused by a hash column integrity violation
-		// http code 409 (Conflict) here
-		if (doc == models.Document{}) {
-			// TODO Change hash UNIQUENESS
-			text := http.StatusText(http.StatusConflict)
-			err := errors.New(text)
-			errChan <- err
-			cancel(err)
-		}
-	}()
-
-	// Saves file to specified directory
-	go func() {
-		defer wg.Done()
-		addPath := dc.Path
-		reader := bytes.NewReader(fileBytes)
-		if err := utils.UploadFile(ctx, addPath, reader, header); err != nil {
-			errChan <- err
-			cancel(err)
-		}
-	}()
-	// Waits goroutines' end
-	wg.Wait()
-	// Close chan
-	close(errChan)
-
-	// If errors occured, write to logs
-	for err := range errChan {
+			// Keep previous content as numbered version
+			// holding reference of its own
+			if _, err := repo.AcquireBlob(ctx, old.Hash, old.Size); err != nil {
+				return err
+			}
+			if _, err := repo.CreateVersion(ctx, old); err != nil {
+				return err
+			}
+		}
+
+		// Update document record
+		doc, err = repo.UpdateDocument(ctx, id, dc)
 		if err != nil {
-			log.Println(err)
+			return err
 		}
+		if doc == (models.Document{}) {
+			return errDocumentNotFound
+		}
+
+		// Document drops reference on previous content
+		if replaced {
+			return repo.ReleaseBlob(ctx, old.Hash)
+		}
+		return nil
+	})
+	if err != nil {
+		undo()
+		// Document was deleted meanwhile
+		if errors.Is(err, errDocumentNotFound) {
+			msg := "Requested document not found"
+			utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+			return
+		}
+		sendDocumentError(w, err, "Database error: could not update document")
+		return
 	}
 
-	// Sends Response
-	select {
-	case <-ctx.Done():
-		cause := context.Cause(ctx)
-		// Choose correct http code for response
-		if cause.Error() == http.StatusText(http.StatusConflict) {
-			msg := "Document already exists"
-			utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
-		} else {
-			msg := "Internal context exceed"
-			utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
-		}
-	default:
-		utils.SendJSONResponse(w, models.ResponseSingleDocument{
-			StatusCode: http.StatusOK,
-			Document:   doc,
-		})
+	if replaced {
+		// Extract text of new content for search
+		s.requestIndexing()
+	} else {
+		// Document holds reference on same content already
+		undo()
 	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleDocument{
+		StatusCode: http.StatusOK,
+		Document:   doc,
+	})
 }
 
-func DownloadDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, path string) {
-	// Build path to file
-	vol := volume.GetPath()
-	path = filepath.Join(vol, path)
-	// Open file
-	file, err := os.Open(path)
+// Applies changed fields of du over fields of doc
+func applyUpdate(doc models.Document, du models.DocumentUpdate) models.DocumentCreation {
+	dc := models.DocumentCreation{
+		AuthorId:    doc.AuthorId,
+		UploaderId:  doc.UploaderId,
+		Title:       doc.Title,
+		Size:        doc.Size,
+		Path:        doc.Path,
+		Hash:        doc.Hash,
+		ContentType: doc.ContentType,
+	}
+	if du.AuthorId != nil {
+		dc.AuthorId = *du.AuthorId
+	}
+	if du.Title != nil {
+		dc.Title = *du.Title
+	}
+	if du.Path != nil {
+		dc.Path = utils.CleanPath(*du.Path)
+	}
+	if du.UploaderId != nil {
+		dc.UploaderId = *du.UploaderId
+	}
+	return dc
+}
+
+func (s *Service) DownloadDocumentById(ctx context.Context, w http.ResponseWriter, r *http.Request, id int) {
+	// Set timeout context for lookup
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check document is visible to user
+	if !policy.AuthorizeDocument(lookupCtx, w, repo, id, aclModels.PermissionRead) {
+		return
+	}
+
+	// Get document
+	doc, err := repo.GetDocumentById(lookupCtx, id)
 	if err != nil {
-		msg := fmt.Sprintf("Could not open file %v", path)
+		msg := "Internal server error"
 		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
 		return
 	}
-	defer file.Close()
-	// Set headers
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", file.Name()))
-	w.Header().Set("Content-Type", "application/octet-stream")
-	// Save file to user
-	_, err = io.Copy(w, file)
+	if doc == (models.Document{}) {
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	// Send content
+	s.serveBlob(ctx, w, r, doc.Title, doc.Hash, doc.ContentType, parseTime(doc.ChangedAt))
+}
+
+// DownloadDocument serves document by its path and title.
+// Deprecated: use DownloadDocumentById.
+func (s *Service) DownloadDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, p string) {
+	// Set timeout context for lookup
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Find document by its path and title,
+	// path is cleaned so it can not point above the root
+	p = utils.CleanPath(p)
+	doc, err := repo.GetDocumentByLocation(lookupCtx, path.Dir(p), path.Base(p))
 	if err != nil {
-		msg := fmt.Sprintf("Could not copy file %v", path)
+		msg := "Internal server error"
 		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
 		return
 	}
+	if doc == (models.Document{}) {
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+	// Check document is visible to user
+	if !policy.AuthorizeDocument(lookupCtx, w, repo, int(doc.Id), aclModels.PermissionRead) {
+		return
+	}
+
+	// Point clients to the endpoint replacing this one
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Link", fmt.Sprintf("</docs/id/%d/content>; rel=\"alternate\"", doc.Id))
+
+	// Send content
+	s.serveBlob(ctx, w, r, doc.Title, doc.Hash, doc.ContentType, parseTime(doc.ChangedAt))
+}
+
+// Reports whether title can be used as file name
+func validTitle(title string) bool {
+	return title != "" && title != "." && title != ".." && !strings.ContainsAny(title, "/\\")
+}
+
+// Sends response matching repository error of document saving
+func sendDocumentError(w http.ResponseWriter, err error, msg string) {
+	// Pre-signed upload URL accepts single upload
+	if errors.Is(err, errUploadClaimed) {
+		msg := "Pre-signed URL was already used"
+		utils.SendJSONErrorResponse(w, http.StatusGone, msg)
+		return
+	}
+	// Path and title identify document
+	if storage.IsUniqueViolation(err) {
+		msg := "Document with such path and title already exists"
+		utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+		return
+	}
+	// Author and uploader must be existing users
+	if storage.IsForeignKeyViolation(err) {
+		msg := "Author or uploader not found"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	log.Println(err)
+	utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
 }
@@ -0,0 +1,620 @@
+package service
+
+import (
+	"context"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/docs/repository"
+	usersModels "docshell/internal/v1/users/models"
+	"docshell/internal/v1/utils"
+	"docshell/internal/v1/volume"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	editor = usersModels.User{Id: 1, Name: "editor", Role: usersModels.RoleEditor}
+	other  = usersModels.User{Id: 2, Name: "other", Role: usersModels.RoleEditor}
+	admin  = usersModels.User{Id: 3, Name: "admin", Role: usersModels.RoleAdmin}
+)
+
+// Repository granting permissions on documents of other uploaders
+type grantingRepository struct {
+	*repository.Memory
+	// Permission of user on document by their ids
+	grants map[[2]int64]string
+}
+
+func (g grantingRepository) GetDocumentPermission(ctx context.Context, id int, userId int64) (string, error) {
+	if permission, ok := g.grants[[2]int64{int64(id), userId}]; ok {
+		return permission, nil
+	}
+	return g.Memory.GetDocumentPermission(ctx, id, userId)
+}
+
+// Returns service keeping documents in memory, there is no
+// database, so only the root folder can be written by non-admins
+func newTestService(t *testing.T, documents repository.DocumentRepository) *Service {
+	t.Helper()
+	signer, err := auth.NewSigner("test secret", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(nil, volume.NewMemoryStorage(), documents, signer, 24*time.Hour)
+}
+
+// Returns context authenticated as user
+func as(user usersModels.User) context.Context {
+	return auth.WithUser(context.Background(), user, 0)
+}
+
+// Stages file with content for upload
+func stage(t *testing.T, s *Service, filename, content string) *utils.StagedFile {
+	t.Helper()
+	file, err := utils.StageFile(context.Background(), s.storage, strings.NewReader(content), filename, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// Runs call and decodes its response into res if it succeeds
+func call(t *testing.T, res any, fn func(w http.ResponseWriter, r *http.Request)) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	fn(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code == http.StatusOK && res != nil {
+		if err := json.NewDecoder(w.Body).Decode(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code
+}
+
+// Creates document with content in the root folder as user
+func create(t *testing.T, s *Service, user usersModels.User, title, content string) models.Document {
+	t.Helper()
+	var res models.ResponseSingleDocument
+	code := call(t, &res, func(w http.ResponseWriter, r *http.Request) {
+		s.CreateDocument(as(user), w, r, stage(t, s, title, content), models.DocumentCreation{AuthorId: user.Id})
+	})
+	if code != http.StatusOK {
+		t.Fatalf("CreateDocument() status = %d, want %d", code, http.StatusOK)
+	}
+	return res.Document
+}
+
+// Downloads content of document as user
+func download(t *testing.T, s *Service, user usersModels.User, id int) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.DownloadDocumentById(as(user), w, httptest.NewRequest(http.MethodGet, "/", nil), id)
+	if w.Code != http.StatusOK {
+		t.Fatalf("DownloadDocumentById() status = %d, want %d", w.Code, http.StatusOK)
+	}
+	return w.Body.String()
+}
+
+func TestCreateUpdateVersions(t *testing.T) {
+	s := newTestService(t, repository.NewMemory())
+	doc := create(t, s, editor, "notes.txt", "first")
+	id := int(doc.Id)
+	if doc.Path != "." || doc.Title != "notes.txt" || doc.UploaderId != editor.Id || doc.Size != 5 {
+		t.Fatalf("CreateDocument() = %+v", doc)
+	}
+
+	// Same path and title can not be taken twice
+	code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		s.CreateDocument(as(editor), w, r, stage(t, s, "notes.txt", "copy"), models.DocumentCreation{AuthorId: editor.Id})
+	})
+	if code != http.StatusConflict {
+		t.Fatalf("CreateDocument() of taken location status = %d, want %d", code, http.StatusConflict)
+	}
+
+	// New content keeps previous one as version
+	update := func(file *utils.StagedFile, du models.DocumentUpdate) models.Document {
+		t.Helper()
+		var res models.ResponseSingleDocument
+		code := call(t, &res, func(w http.ResponseWriter, r *http.Request) {
+			s.UpdateDocument(as(editor), w, r, id, file, du)
+		})
+		if code != http.StatusOK {
+			t.Fatalf("UpdateDocument() status = %d, want %d", code, http.StatusOK)
+		}
+		return res.Document
+	}
+	updated := update(stage(t, s, "notes.txt", "second"), models.DocumentUpdate{})
+	if updated.Id != doc.Id || updated.Hash == doc.Hash || updated.Size != 6 {
+		t.Fatalf("UpdateDocument() = %+v", updated)
+	}
+	if got := download(t, s, editor, id); got != "second" {
+		t.Fatalf("content = %q, want %q", got, "second")
+	}
+
+	// Same content and metadata changes add no version
+	update(stage(t, s, "notes.txt", "second"), models.DocumentUpdate{})
+	title := "renamed.txt"
+	if renamed := update(nil, models.DocumentUpdate{Title: &title}); renamed.Title != title {
+		t.Fatalf("UpdateDocument() title = %q, want %q", renamed.Title, title)
+	}
+
+	var versions models.ResponseMultipleVersions
+	if code := call(t, &versions, func(w http.ResponseWriter, r *http.Request) {
+		s.GetVersions(as(editor), w, r, id)
+	}); code != http.StatusOK {
+		t.Fatalf("GetVersions() status = %d, want %d", code, http.StatusOK)
+	}
+	if len(versions.Versions) != 1 {
+		t.Fatalf("GetVersions() = %+v, want one version", versions.Versions)
+	}
+	if v := versions.Versions[0]; v.Version != 1 || v.Hash != doc.Hash || v.Size != doc.Size {
+		t.Fatalf("version = %+v, want content of %+v", v, doc)
+	}
+
+	// Invalid title is rejected
+	bad := "a/b"
+	if code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		s.UpdateDocument(as(editor), w, r, id, nil, models.DocumentUpdate{Title: &bad})
+	}); code != http.StatusBadRequest {
+		t.Fatalf("UpdateDocument() with title %q status = %d, want %d", bad, code, http.StatusBadRequest)
+	}
+}
+
+func TestUpdateBlobReferences(t *testing.T) {
+	repo := repository.NewMemory()
+	s := newTestService(t, repo)
+	doc := create(t, s, editor, "notes.txt", "first")
+	id := int(doc.Id)
+
+	// Returns count of references on blob with hash
+	refs := func(hash string) int {
+		t.Helper()
+		blob, err := repo.AcquireBlob(context.Background(), hash, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.ReleaseBlob(context.Background(), hash); err != nil {
+			t.Fatal(err)
+		}
+		return blob.RefCount - 1
+	}
+	update := func(file *utils.StagedFile, du models.DocumentUpdate) (models.Document, int) {
+		t.Helper()
+		var res models.ResponseSingleDocument
+		code := call(t, &res, func(w http.ResponseWriter, r *http.Request) {
+			s.UpdateDocument(as(editor), w, r, id, file, du)
+		})
+		return res.Document, code
+	}
+
+	// Version holds reference on previous content, document on new one
+	second, code := update(stage(t, s, "notes.txt", "second"), models.DocumentUpdate{})
+	if code != http.StatusOK {
+		t.Fatalf("UpdateDocument() status = %d, want %d", code, http.StatusOK)
+	}
+	if _, code := update(stage(t, s, "notes.txt", "second"), models.DocumentUpdate{}); code != http.StatusOK {
+		t.Fatalf("UpdateDocument() status = %d, want %d", code, http.StatusOK)
+	}
+	if got := refs(doc.Hash); got != 1 {
+		t.Fatalf("references on first content = %d, want 1", got)
+	}
+	if got := refs(second.Hash); got != 1 {
+		t.Fatalf("references on second content = %d, want 1", got)
+	}
+
+	// Reverted document and both versions hold references
+	if code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		s.RevertDocument(as(editor), w, r, id, 1)
+	}); code != http.StatusOK {
+		t.Fatalf("RevertDocument() status = %d, want %d", code, http.StatusOK)
+	}
+	if got := refs(doc.Hash); got != 2 {
+		t.Fatalf("references on first content after revert = %d, want 2", got)
+	}
+	if got := refs(second.Hash); got != 1 {
+		t.Fatalf("references on second content after revert = %d, want 1", got)
+	}
+
+	// Failed update keeps versions and references as they were
+	create(t, s, editor, "taken.txt", "other")
+	title := "taken.txt"
+	third := stage(t, s, "notes.txt", "third")
+	if _, code := update(third, models.DocumentUpdate{Title: &title}); code != http.StatusConflict {
+		t.Fatalf("UpdateDocument() to taken location status = %d, want %d", code, http.StatusConflict)
+	}
+	if got := refs(third.Hash); got != 0 {
+		t.Fatalf("references on content of failed update = %d, want 0", got)
+	}
+	if got := refs(doc.Hash); got != 2 {
+		t.Fatalf("references on first content after failed update = %d, want 2", got)
+	}
+	versions, err := repo.GetVersions(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("versions after failed update = %+v, want two", versions)
+	}
+}
+
+// Repository where every blob is acquired again right after its record is
+// deleted, as if upload of same content came before the file is removed
+type reacquiringRepository struct {
+	*repository.Memory
+}
+
+func (r reacquiringRepository) DeleteUnreferencedBlobs(ctx context.Context) ([]string, error) {
+	hashes, err := r.Memory.DeleteUnreferencedBlobs(ctx)
+	for _, hash := range hashes {
+		if _, err := r.AcquireBlob(ctx, hash, 0); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, err
+}
+
+func TestCollectGarbage(t *testing.T) {
+	// Stores blob and drops its only reference
+	unreferenced := func(s *Service) string {
+		t.Helper()
+		ctx := context.Background()
+		blob, err := storeBlob(ctx, s.documents, stage(t, s, "notes.txt", "content"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.documents.ReleaseBlob(ctx, blob.Hash); err != nil {
+			t.Fatal(err)
+		}
+		return blob.Hash
+	}
+	stored := func(s *Service, hash string) bool {
+		t.Helper()
+		_, err := s.storage.Stat(context.Background(), volume.GetBlobKey(hash))
+		if err != nil && !errors.Is(err, volume.ErrNotExist) {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+
+	s := newTestService(t, repository.NewMemory())
+	kept := create(t, s, editor, "kept.txt", "kept")
+	hash := unreferenced(s)
+	if err := s.CollectGarbage(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if stored(s, hash) {
+		t.Fatal("CollectGarbage() kept unreferenced blob")
+	}
+	if !stored(s, kept.Hash) {
+		t.Fatal("CollectGarbage() removed blob of document")
+	}
+
+	// Blob acquired while it is collected keeps its file
+	s = newTestService(t, reacquiringRepository{repository.NewMemory()})
+	hash = unreferenced(s)
+	if err := s.CollectGarbage(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !stored(s, hash) {
+		t.Fatal("CollectGarbage() removed blob acquired again")
+	}
+}
+
+func TestRemoveStaleUploads(t *testing.T) {
+	root := t.TempDir()
+	store, err := volume.NewLocalStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestService(t, repository.NewMemory())
+	s.storage = store
+
+	doc := create(t, s, editor, "notes.txt", "stored")
+	stale := stage(t, s, "stale.txt", "stale")
+	fresh := stage(t, s, "fresh.txt", "fresh")
+	old := time.Now().Add(-2 * staleUploadAge)
+	if err := os.Chtimes(filepath.Join(root, filepath.FromSlash(stale.Key)), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RemoveStaleUploads(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{
+		stale.Key:                   false,
+		fresh.Key:                   true,
+		volume.GetBlobKey(doc.Hash): true,
+	} {
+		_, err := store.Stat(context.Background(), key)
+		if err != nil && !errors.Is(err, volume.ErrNotExist) {
+			t.Fatal(err)
+		}
+		if got := err == nil; got != want {
+			t.Errorf("file %v kept = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestCreateDocumentTitle(t *testing.T) {
+	repo := repository.NewMemory()
+	s := newTestService(t, repo)
+	for _, filename := range []string{"", ".", "..", "a/b.txt", `dir\name.txt`} {
+		code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			s.CreateDocument(as(editor), w, r, stage(t, s, filename, "content"), models.DocumentCreation{AuthorId: editor.Id})
+		})
+		if code != http.StatusBadRequest {
+			t.Errorf("CreateDocument() of file %q status = %d, want %d", filename, code, http.StatusBadRequest)
+		}
+	}
+	docs, _, err := repo.GetAllDocuments(context.Background(), editor.Id, true, models.DocumentFilter{Sort: "id", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 0 {
+		t.Fatalf("documents = %v, want none", docs)
+	}
+}
+
+func TestTrashRestore(t *testing.T) {
+	s := newTestService(t, repository.NewMemory())
+	doc := create(t, s, editor, "report.pdf", "content")
+	id := int(doc.Id)
+
+	list := func(user usersModels.User, fn func(ctx context.Context, w http.ResponseWriter, r *http.Request)) []int64 {
+		t.Helper()
+		var res models.ResponseMultipleDocuments
+		if code := call(t, &res, func(w http.ResponseWriter, r *http.Request) {
+			fn(as(user), w, r)
+		}); code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", code, http.StatusOK)
+		}
+		ids := []int64{}
+		for _, doc := range res.Documents {
+			ids = append(ids, doc.Id)
+		}
+		return ids
+	}
+	documents := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		s.GetAllDocuments(ctx, w, r, false, models.DocumentFilter{Sort: "id", Limit: 10})
+	}
+	trash := func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		s.GetTrash(ctx, w, r)
+	}
+
+	// Document of other user can not be deleted
+	if code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		s.DeleteDocument(as(other), w, r, id)
+	}); code != http.StatusNotFound {
+		t.Fatalf("DeleteDocument() by other user status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	var deleted models.ResponseSingleDocument
+	if code := call(t, &deleted, func(w http.ResponseWriter, r *http.Request) {
+		s.DeleteDocument(as(editor), w, r, id)
+	}); code != http.StatusOK {
+		t.Fatalf("DeleteDocument() status = %d, want %d", code, http.StatusOK)
+	}
+	if deleted.Document.DeletedAt == nil {
+		t.Fatal("DeleteDocument() did not set deleted_at")
+	}
+	if ids := list(editor, documents); len(ids) != 0 {
+		t.Fatalf("documents = %v, want none", ids)
+	}
+	if ids := list(editor, trash); !slices.Equal(ids, []int64{doc.Id}) {
+		t.Fatalf("trash = %v, want [%d]", ids, doc.Id)
+	}
+	if ids := list(other, trash); len(ids) != 0 {
+		t.Fatalf("trash of other user = %v, want none", ids)
+	}
+	if ids := list(admin, trash); !slices.Equal(ids, []int64{doc.Id}) {
+		t.Fatalf("trash of admin = %v, want [%d]", ids, doc.Id)
+	}
+
+	// Deleted document can not be changed
+	if code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		s.UpdateDocument(as(editor), w, r, id, stage(t, s, "report.pdf", "changed"), models.DocumentUpdate{})
+	}); code != http.StatusNotFound {
+		t.Fatalf("UpdateDocument() of deleted document status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	// Location of document in trash may be taken meanwhile
+	taken := create(t, s, editor, "report.pdf", "newer")
+	restore := func() int {
+		return call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			s.RestoreDocument(as(editor), w, r, id)
+		})
+	}
+	if code := restore(); code != http.StatusConflict {
+		t.Fatalf("RestoreDocument() to taken location status = %d, want %d", code, http.StatusConflict)
+	}
+	if code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		s.DeleteDocument(as(editor), w, r, int(taken.Id))
+	}); code != http.StatusOK {
+		t.Fatalf("DeleteDocument() status = %d, want %d", code, http.StatusOK)
+	}
+
+	if code := restore(); code != http.StatusOK {
+		t.Fatalf("RestoreDocument() status = %d, want %d", code, http.StatusOK)
+	}
+	if ids := list(editor, documents); !slices.Equal(ids, []int64{doc.Id}) {
+		t.Fatalf("documents = %v, want [%d]", ids, doc.Id)
+	}
+	if got := download(t, s, editor, id); got != "content" {
+		t.Fatalf("content = %q, want %q", got, "content")
+	}
+	if code := restore(); code != http.StatusNotFound {
+		t.Fatalf("RestoreDocument() of document not in trash status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestGetAllDocumentsPages(t *testing.T) {
+	s := newTestService(t, repository.NewMemory())
+	var want []int64
+	for i := range 7 {
+		// Titles are ordered unlike ids
+		doc := create(t, s, editor, fmt.Sprintf("doc-%d.txt", 6-i), fmt.Sprintf("content %d", i))
+		want = append(want, doc.Id)
+	}
+	create(t, s, other, "doc-of-other.txt", "other")
+
+	page := func(user usersModels.User, f models.DocumentFilter) (int, models.ResponseMultipleDocuments) {
+		var res models.ResponseMultipleDocuments
+		code := call(t, &res, func(w http.ResponseWriter, r *http.Request) {
+			s.GetAllDocuments(as(user), w, r, false, f)
+		})
+		return code, res
+	}
+	// Follows next_cursor until the last page
+	all := func(user usersModels.User, f models.DocumentFilter) []int64 {
+		t.Helper()
+		ids := []int64{}
+		for range 10 {
+			code, res := page(user, f)
+			if code != http.StatusOK {
+				t.Fatalf("GetAllDocuments() status = %d, want %d", code, http.StatusOK)
+			}
+			if len(res.Documents) > f.Limit {
+				t.Fatalf("GetAllDocuments() returned %d documents, limit is %d", len(res.Documents), f.Limit)
+			}
+			for _, doc := range res.Documents {
+				ids = append(ids, doc.Id)
+			}
+			if res.NextCursor == "" {
+				return ids
+			}
+			f.Cursor = res.NextCursor
+		}
+		t.Fatal("GetAllDocuments() pages do not end")
+		return nil
+	}
+
+	if got := all(editor, models.DocumentFilter{Sort: "id", Limit: 3}); !slices.Equal(got, want) {
+		t.Fatalf("documents by id = %v, want %v", got, want)
+	}
+	reversed := slices.Clone(want)
+	slices.Reverse(reversed)
+	if got := all(editor, models.DocumentFilter{Sort: "title", Limit: 2}); !slices.Equal(got, reversed) {
+		t.Fatalf("documents by title = %v, want %v", got, reversed)
+	}
+	if got := all(editor, models.DocumentFilter{Sort: "id", Desc: true, Limit: 4}); !slices.Equal(got, reversed) {
+		t.Fatalf("documents by id descending = %v, want %v", got, reversed)
+	}
+	if got := all(editor, models.DocumentFilter{Sort: "id", Limit: 7}); !slices.Equal(got, want) {
+		t.Fatalf("documents in single page = %v, want %v", got, want)
+	}
+	if got := all(admin, models.DocumentFilter{Sort: "id", Limit: 5}); len(got) != 8 {
+		t.Fatalf("documents of admin = %v, want all 8", got)
+	}
+
+	// Cursor is bound to order it was issued for
+	_, first := page(editor, models.DocumentFilter{Sort: "id", Limit: 3})
+	for _, f := range []models.DocumentFilter{
+		{Sort: "title", Limit: 3, Cursor: first.NextCursor},
+		{Sort: "id", Desc: true, Limit: 3, Cursor: first.NextCursor},
+		{Sort: "id", Limit: 3, Cursor: "not a cursor"},
+	} {
+		if code, _ := page(editor, f); code != http.StatusBadRequest {
+			t.Fatalf("GetAllDocuments() with cursor %q sorted by %s status = %d, want %d",
+				f.Cursor, f.Sort, code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestDocumentPermissions(t *testing.T) {
+	repo := grantingRepository{Memory: repository.NewMemory(), grants: map[[2]int64]string{}}
+	s := newTestService(t, repo)
+	doc := create(t, s, editor, "plan.txt", "plan")
+	id := int(doc.Id)
+
+	get := func(user usersModels.User) int {
+		return call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			s.GetDocumentById(as(user), w, r, id, false)
+		})
+	}
+	rename := func(user usersModels.User, title string) int {
+		return call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			s.UpdateDocument(as(user), w, r, id, nil, models.DocumentUpdate{Title: &title})
+		})
+	}
+	remove := func(user usersModels.User) int {
+		return call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			s.DeleteDocument(as(user), w, r, id)
+		})
+	}
+
+	// Permissions reported by repository
+	for _, tt := range []struct {
+		user       usersModels.User
+		permission string
+	}{
+		{user: editor, permission: aclModels.PermissionWrite},
+		{user: other, permission: ""},
+	} {
+		got, err := repo.GetDocumentPermission(context.Background(), id, tt.user.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.permission {
+			t.Fatalf("GetDocumentPermission() of %s = %q, want %q", tt.user.Name, got, tt.permission)
+		}
+	}
+
+	// Document without grant is hidden
+	if code := get(other); code != http.StatusNotFound {
+		t.Fatalf("GetDocumentById() without grant status = %d, want %d", code, http.StatusNotFound)
+	}
+	if code := rename(other, "taken.txt"); code != http.StatusNotFound {
+		t.Fatalf("UpdateDocument() without grant status = %d, want %d", code, http.StatusNotFound)
+	}
+	if code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		s.DownloadDocumentById(as(other), w, r, id)
+	}); code != http.StatusNotFound {
+		t.Fatalf("DownloadDocumentById() without grant status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	// Read grant allows reading only
+	repo.grants[[2]int64{doc.Id, other.Id}] = aclModels.PermissionRead
+	if code := get(other); code != http.StatusOK {
+		t.Fatalf("GetDocumentById() with read grant status = %d, want %d", code, http.StatusOK)
+	}
+	if got := download(t, s, other, id); got != "plan" {
+		t.Fatalf("content = %q, want %q", got, "plan")
+	}
+	if code := rename(other, "read.txt"); code != http.StatusForbidden {
+		t.Fatalf("UpdateDocument() with read grant status = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := remove(other); code != http.StatusForbidden {
+		t.Fatalf("DeleteDocument() with read grant status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	// Write grant allows changes
+	repo.grants[[2]int64{doc.Id, other.Id}] = aclModels.PermissionWrite
+	if code := rename(other, "written.txt"); code != http.StatusOK {
+		t.Fatalf("UpdateDocument() with write grant status = %d, want %d", code, http.StatusOK)
+	}
+
+	// Admin needs no grant
+	if code := get(admin); code != http.StatusOK {
+		t.Fatalf("GetDocumentById() by admin status = %d, want %d", code, http.StatusOK)
+	}
+	if code := remove(admin); code != http.StatusOK {
+		t.Fatalf("DeleteDocument() by admin status = %d, want %d", code, http.StatusOK)
+	}
+
+	// Missing document is not found for anyone
+	if code := call(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		s.GetDocumentById(as(admin), w, r, id+100, false)
+	}); code != http.StatusNotFound {
+		t.Fatalf("GetDocumentById() of missing document status = %d, want %d", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/storage"
+	util "docshell/internal/v1/users"
+	"docshell/internal/v1/utils"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Length of token start kept to recognize share
+const sharePrefixLength = 8
+
+func (s *Service) CreateShare(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, sc models.ShareCreation) {
+	// Validate fields
+	if sc.ExpiresAt != nil && !sc.ExpiresAt.After(time.Now()) {
+		msg := "Expiry time is in the past"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if sc.MaxDownloads != nil && *sc.MaxDownloads <= 0 {
+		msg := fmt.Sprintf("Max downloads '%v' incorrect", *sc.MaxDownloads)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Hash password
+	var passwordHash *string
+	if sc.Password != nil {
+		if len(*sc.Password) < util.MinPasswordLength {
+			msg := fmt.Sprintf("Password must be at least %d characters", util.MinPasswordLength)
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+		hash, err := util.HashPassword(*sc.Password)
+		if err != nil {
+			log.Println(err)
+			msg := "Password hashing fault"
+			utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+			return
+		}
+		passwordHash = &hash
+	}
+
+	// Generate token
+	token, err := auth.NewShareToken()
+	if err != nil {
+		log.Println(err)
+		msg := "Share link could not be issued"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check user may share document
+	if !policy.AuthorizeDocument(ctx, w, s.documents, id, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Save share
+	user, _ := auth.UserFromContext(ctx)
+	share, err := repository.CreateShare(ctx, con, id, user.Id, token[:sharePrefixLength],
+		auth.HashToken(token), sc.ExpiresAt, sc.MaxDownloads, passwordHash)
+	if err != nil {
+		// Document does not exist
+		if storage.IsForeignKeyViolation(err) {
+			msg := "Requested document not found"
+			utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+			return
+		}
+		log.Println(err)
+		msg := "Database error: could not save share"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Token is shown only once
+	w.Header().Set("Cache-Control", "no-store")
+	utils.SendJSONResponse(w, models.ResponseCreatedShare{
+		StatusCode: http.StatusOK,
+		Share:      share,
+		Token:      token,
+		Url:        "/s/" + token,
+	})
+}
+
+func (s *Service) GetShares(ctx context.Context, w http.ResponseWriter, r *http.Request, id int) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check user may share document
+	if !policy.AuthorizeDocument(ctx, w, s.documents, id, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Get shares of document
+	shares, err := repository.GetDocumentShares(ctx, con, id)
+	if err != nil {
+		msg := "Database error: could not read shares"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleShares{
+		StatusCode: http.StatusOK,
+		Shares:     make([]models.Share, len(shares)),
+	}
+	copy(res.Shares, shares)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) RevokeShare(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, shareId int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check user may share document
+	if !policy.AuthorizeDocument(ctx, w, s.documents, id, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Revoke share, link stops working at once
+	share, err := repository.RevokeShare(ctx, con, shareId, id)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not revoke share"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if share == (models.Share{}) {
+		msg := "Requested share not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleShare{
+		StatusCode: http.StatusOK,
+		Share:      share,
+	})
+}
+
+func (s *Service) GetShareDownloads(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, shareId int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check user may share document
+	if !policy.AuthorizeDocument(ctx, w, s.documents, id, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Get downloads
+	downloads, err := repository.GetShareDownloads(ctx, con, shareId, id)
+	if err != nil {
+		msg := "Database error: could not read downloads"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleShareDownloads{
+		StatusCode: http.StatusOK,
+		Downloads:  make([]models.ShareDownload, len(downloads)),
+	}
+	copy(res.Downloads, downloads)
+	utils.SendJSONResponse(w, res)
+}
+
+// DownloadShare serves document of share link without authentication.
+// Every GET counts as download, HEAD does not.
+func (s *Service) DownloadShare(ctx context.Context, w http.ResponseWriter, r *http.Request, token string) {
+	// Set timeout context for lookup
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Link must not leak through Referer of inline documents
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	// Check query before download is counted
+	if _, ok := readDisposition(w, r); !ok {
+		return
+	}
+
+	// Find share by token
+	share, err := repository.GetActiveShare(lookupCtx, con, auth.HashToken(token))
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if share == (models.Share{}) {
+		msg := "Share link not found or expired"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+	if share.MaxDownloads != nil && share.DownloadCount >= *share.MaxDownloads {
+		msg := "Share link download limit reached"
+		utils.SendJSONErrorResponse(w, http.StatusGone, msg)
+		return
+	}
+
+	// Password is sent with HTTP Basic authentication, user name is ignored
+	if share.PasswordHash != nil {
+		_, password, ok := r.BasicAuth()
+		if !ok || !util.CheckPassword(password, *share.PasswordHash) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="docshell share", charset="UTF-8"`)
+			msg := "Share link password required"
+			utils.SendJSONErrorResponse(w, http.StatusUnauthorized, msg)
+			return
+		}
+	}
+
+	// Get document, deleted ones are not served
+	doc, err := s.documents.GetDocumentById(lookupCtx, int(share.DocumentId))
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if doc == (models.Document{}) {
+		msg := "Share link not found or expired"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	// Count and record download, range requests are counted too,
+	// so content can not be read in parts beyond the limit
+	if r.Method != http.MethodHead {
+		remoteAddr, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			remoteAddr = r.RemoteAddr
+		}
+		ok, err := repository.ClaimShareDownload(lookupCtx, con, share.Id, remoteAddr, r.UserAgent())
+		if err != nil {
+			log.Println(err)
+			msg := "Internal server error"
+			utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+			return
+		}
+		// Used up or revoked meanwhile
+		if !ok {
+			msg := "Share link download limit reached"
+			utils.SendJSONErrorResponse(w, http.StatusGone, msg)
+			return
+		}
+	}
+
+	// Send content
+	w.Header().Set("Cache-Control", "private, no-store")
+	s.serveBlob(ctx, w, r, doc.Title, doc.Hash, doc.ContentType, parseTime(doc.ChangedAt))
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/storage"
+	"docshell/internal/v1/utils"
+	"log"
+	"net/http"
+	"time"
+)
+
+// How often trash and unreferenced blobs are cleaned up
+const cleanupInterval = time.Hour
+
+func (s *Service) DeleteDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, id int) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check user may delete document
+	if !policy.AuthorizeDocument(ctx, w, repo, id, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Mark document as deleted, its blob is kept until purge
+	deleted, err := repo.TrashDocument(ctx, id)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not delete document"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if deleted == (models.Document{}) {
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleDocument{
+		StatusCode: http.StatusOK,
+		Document:   deleted,
+	})
+}
+
+func (s *Service) GetTrash(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Get deleted documents user may restore
+	user, _ := auth.UserFromContext(ctx)
+	docs, err := repo.GetDeletedDocuments(ctx, user.Id, policy.Can(user, policy.AccessAllDocuments))
+	if err != nil {
+		msg := "Database error: could not read docs"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleDocuments{
+		StatusCode: http.StatusOK,
+		Documents:  make([]models.Document, len(docs)),
+	}
+	copy(res.Documents, docs)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) RestoreDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, id int) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check user may restore document
+	if !policy.AuthorizeDocument(ctx, w, repo, id, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Unmark document as deleted
+	restored, err := repo.RestoreDocument(ctx, id)
+	if err != nil {
+		// Other document took its path and title meanwhile
+		if storage.IsUniqueViolation(err) {
+			msg := "Document with such path and title already exists"
+			utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+			return
+		}
+		log.Println(err)
+		msg := "Database error: could not restore document"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if restored == (models.Document{}) {
+		msg := "Requested document not found in trash"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+	// Folders of document may be deleted meanwhile
+	if err := repo.EnsureFolders(ctx, restored.Path); err != nil {
+		log.Println(err)
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleDocument{
+		StatusCode: http.StatusOK,
+		Document:   restored,
+	})
+}
+
+// PurgeTrash permanently removes documents deleted longer than retention ago
+func (s *Service) PurgeTrash(ctx context.Context, retention time.Duration) error {
+	// Get repository
+	repo := s.documents
+
+	// Get expired documents
+	docs, err := repo.GetExpiredDocuments(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		// Remove record and release its blobs
+		if err := repo.PurgeDocument(ctx, doc.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunCleanup purges trash, collects unreferenced blobs, removes
+// staged files of unfinished uploads and forgets expired upload
+// nonces periodically until ctx is done
+func (s *Service) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.PurgeTrash(ctx, s.retention); err != nil {
+			log.Printf("Trash purge fault with %v", err)
+		}
+		if err := s.CollectGarbage(ctx); err != nil {
+			log.Printf("Blob collection fault with %v", err)
+		}
+		if err := s.RemoveStaleUploads(ctx); err != nil {
+			log.Printf("Staging cleanup fault with %v", err)
+		}
+		if err := repository.DeleteExpiredPresignNonces(ctx, s.db); err != nil {
+			log.Printf("Nonce cleanup fault with %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
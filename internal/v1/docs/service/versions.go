@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/docs/models"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+func (s *Service) GetVersions(ctx context.Context, w http.ResponseWriter, r *http.Request, id int) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check document is visible to user
+	if !policy.AuthorizeDocument(ctx, w, repo, id, aclModels.PermissionRead) {
+		return
+	}
+
+	// Check document exists
+	doc, err := repo.GetDocumentById(ctx, id)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if doc == (models.Document{}) {
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	// Get versions of document
+	versions, err := repo.GetVersions(ctx, id)
+	if err != nil {
+		msg := "Database error: could not read versions"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleVersions{
+		StatusCode: http.StatusOK,
+		Versions:   make([]models.DocumentVersion, len(versions)),
+	}
+	copy(res.Versions, versions)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) DownloadVersion(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, version int) {
+	// Get document and its version
+	doc, v, ok := s.getVersion(ctx, w, id, version, aclModels.PermissionRead)
+	if !ok {
+		return
+	}
+
+	// Send content
+	s.serveBlob(ctx, w, r, doc.Title, v.Hash, v.ContentType, parseTime(v.CreatedAt))
+}
+
+// RevertDocument replaces document content with given version,
+// current content is kept as a new version
+func (s *Service) RevertDocument(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, version int) {
+	// Get document and its version
+	_, v, ok := s.getVersion(ctx, w, id, version, aclModels.PermissionWrite)
+	if !ok {
+		return
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Take reference on version content for the document
+	content, err := repo.AcquireBlob(ctx, v.Hash, v.Size)
+	if err != nil {
+		log.Println(err)
+		msg := fmt.Sprintf("Could not read version %v", v.Version)
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Replace content keeping metadata, reverting user becomes uploader
+	user, _ := auth.UserFromContext(ctx)
+	s.updateDocument(ctx, w, id, &content, v.ContentType, models.DocumentUpdate{UploaderId: &user.Id})
+}
+
+// Reads document and its version, sends error response if not found
+func (s *Service) getVersion(ctx context.Context, w http.ResponseWriter, id int, version int, permission string) (
+	models.Document, models.DocumentVersion, bool) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get repository
+	repo := s.documents
+
+	// Check user has permission on document
+	if !policy.AuthorizeDocument(ctx, w, repo, id, permission) {
+		return models.Document{}, models.DocumentVersion{}, false
+	}
+
+	// Get document
+	doc, err := repo.GetDocumentById(ctx, id)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return models.Document{}, models.DocumentVersion{}, false
+	}
+	if doc == (models.Document{}) {
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return models.Document{}, models.DocumentVersion{}, false
+	}
+
+	// Get version
+	v, err := repo.GetVersion(ctx, id, version)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return models.Document{}, models.DocumentVersion{}, false
+	}
+	if v == (models.DocumentVersion{}) {
+		msg := fmt.Sprintf("Version %v of document not found", version)
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return models.Document{}, models.DocumentVersion{}, false
+	}
+
+	return doc, v, true
+}
@@ -0,0 +1,83 @@
+// Package extract reads plain text out of document content for search
+package extract
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Size limits of extraction
+const (
+	// Longer text is cut, tsvector can not hold much more
+	MaxTextSize = 256 << 10
+	// Larger files are not read whole
+	MaxFileSize = 32 << 20
+)
+
+// Characters marking matches in search snippets, removed from extracted text
+const (
+	MarkStart = "\uE000"
+	MarkStop  = "\uE001"
+)
+
+var (
+	ErrUnsupported = errors.New("content type is not supported")
+	ErrTooLarge    = errors.New("file is too large for extraction")
+)
+
+// Text returns text of content of contentType. Plain text, Markdown,
+// HTML and text layer of PDF are supported, ErrUnsupported is
+// returned for other types.
+func Text(r io.Reader, contentType string) (string, error) {
+	base, _, _ := mime.ParseMediaType(contentType)
+	var text string
+	switch {
+	case base == "text/html" || base == "application/xhtml+xml":
+		data, err := readAll(r)
+		if err != nil {
+			return "", err
+		}
+		text = htmlText(data)
+	case base == "application/pdf":
+		data, err := readAll(r)
+		if err != nil {
+			return "", err
+		}
+		text = pdfText(data)
+	case strings.HasPrefix(base, "text/"):
+		// Markdown is searched as written
+		data, err := io.ReadAll(io.LimitReader(r, MaxTextSize))
+		if err != nil {
+			return "", err
+		}
+		text = string(data)
+	default:
+		return "", ErrUnsupported
+	}
+	return clean(text), nil
+}
+
+// Reads whole file up to MaxFileSize
+func readAll(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxFileSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// Makes text storable in database: valid UTF-8 without
+// NUL and mark characters, at most MaxTextSize bytes
+func clean(text string) string {
+	// Rune cut in half is dropped as invalid
+	if len(text) > MaxTextSize {
+		text = text[:MaxTextSize]
+	}
+	return strings.NewReplacer("\x00", "", MarkStart, "", MarkStop, "").
+		Replace(strings.ToValidUTF8(text, ""))
+}
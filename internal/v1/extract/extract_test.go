@@ -0,0 +1,33 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func FuzzText(f *testing.F) {
+	f.Add([]byte("# Title\n\nSome *Markdown*"), "text/markdown; charset=utf-8")
+	f.Add([]byte("<p>a<script>b</script>c &amp; d<!-- e --></p>"), "text/html")
+	f.Add(pdfWithStream("", []byte("BT (pdf) Tj ET")), "application/pdf")
+	f.Add([]byte("nul \x00 and marks  \xff"), "text/plain")
+	f.Add([]byte{0x89, 'P', 'N', 'G'}, "image/png")
+	f.Fuzz(func(t *testing.T, data []byte, contentType string) {
+		text, err := Text(bytes.NewReader(data), contentType)
+		if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrTooLarge) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Text() error = %v", err)
+		}
+		// Text must be storable in database
+		if !utf8.ValidString(text) || len(text) > MaxTextSize {
+			t.Fatalf("Text() = %q, not valid UTF-8 or too long", text)
+		}
+		if strings.ContainsAny(text, "\x00"+MarkStart+MarkStop) {
+			t.Fatalf("Text() = %q, has NUL or mark characters", text)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package extract
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// Elements whose content is not text
+var skippedElements = []string{"script", "style", "noscript", "template", "svg"}
+
+// Returns text of HTML document without tags, comments, scripts
+// and styles. Every tag separates words.
+func htmlText(data []byte) string {
+	var out strings.Builder
+	for len(data) > 0 && out.Len() < MaxTextSize {
+		i := bytes.IndexByte(data, '<')
+		if i < 0 {
+			out.WriteString(html.UnescapeString(string(data)))
+			break
+		}
+		out.WriteString(html.UnescapeString(string(data[:i])))
+		out.WriteByte(' ')
+		data = data[i:]
+
+		// Comments may contain '>'
+		if bytes.HasPrefix(data, []byte("<!--")) {
+			data = skipPast(data[4:], []byte("-->"))
+			continue
+		}
+
+		// Skip tag, content of some elements is skipped with it
+		end := bytes.IndexByte(data, '>')
+		if end < 0 {
+			break
+		}
+		name := tagName(data[1:end])
+		data = data[end+1:]
+		for _, skipped := range skippedElements {
+			if name == skipped {
+				data = skipPast(skipPast(data, []byte("</"+skipped)), []byte(">"))
+				break
+			}
+		}
+	}
+	return out.String()
+}
+
+// Returns lowercase name of tag with content s
+func tagName(s []byte) string {
+	end := bytes.IndexAny(s, " \t\r\n/>")
+	if end == 0 {
+		return ""
+	}
+	if end > 0 {
+		s = s[:end]
+	}
+	return strings.ToLower(string(s))
+}
+
+// Returns data after first occurrence of sep, case insensitive
+func skipPast(data, sep []byte) []byte {
+	i := bytes.Index(bytes.ToLower(data), sep)
+	if i < 0 {
+		return nil
+	}
+	return data[i+len(sep):]
+}
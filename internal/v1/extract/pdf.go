@@ -0,0 +1,250 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Decompressed size of single stream is limited against zip bombs
+const maxStreamSize = 16 << 20
+
+// Returns text shown by content streams of PDF document. Only text written
+// with standard encodings is found, text of fonts with custom encodings
+// and of compressed object streams is missed.
+func pdfText(data []byte) string {
+	var out strings.Builder
+	for len(data) > 0 && out.Len() < MaxTextSize {
+		// Find next stream and its dictionary
+		i := bytes.Index(data, []byte("stream"))
+		if i < 0 {
+			break
+		}
+		dict := data[:i]
+		if obj := bytes.LastIndex(dict, []byte(" obj")); obj >= 0 {
+			dict = dict[obj:]
+		}
+		data = data[i+len("stream"):]
+		data = bytes.TrimPrefix(data, []byte("\r"))
+		data = bytes.TrimPrefix(data, []byte("\n"))
+		end := bytes.Index(data, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[:end]
+		data = data[end+len("endstream"):]
+
+		if content, ok := decodeStream(dict, raw); ok {
+			contentText(content, &out)
+		}
+	}
+	return out.String()
+}
+
+// Decodes stream with dictionary dict, false is returned for
+// streams which can not contain page content
+func decodeStream(dict, raw []byte) ([]byte, bool) {
+	// Fonts and images
+	for _, key := range []string{"/Length1", "/FontFile", "/Image", "/XRef", "/ObjStm"} {
+		if bytes.Contains(dict, []byte(key)) {
+			return nil, false
+		}
+	}
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return raw, true
+	}
+	// Only single Flate filter is supported
+	if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("Decode [")) {
+		return nil, false
+	}
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	defer r.Close()
+	// Truncated streams are common, decoded part is used
+	content, _ := io.ReadAll(io.LimitReader(r, maxStreamSize))
+	return content, len(content) > 0
+}
+
+// Writes strings shown by text operators of content stream into out
+func contentText(c []byte, out *strings.Builder) {
+	inText, inArray := false, false
+	// Strings shown by next operator
+	var shown []string
+	for i := 0; i < len(c); {
+		switch ch := c[i]; {
+		case ch == '(':
+			s, n := literalString(c[i:])
+			if inText {
+				shown = append(shown, s)
+			}
+			i += n
+		case ch == '<' && i+1 < len(c) && c[i+1] == '<':
+			i += 2
+		case ch == '<':
+			s, n := hexString(c[i:])
+			if inText {
+				shown = append(shown, s)
+			}
+			i += n
+		case ch == '[':
+			inArray = true
+			i++
+		case ch == ']':
+			inArray = false
+			i++
+		case ch == '%':
+			for i < len(c) && c[i] != '\n' && c[i] != '\r' {
+				i++
+			}
+		case isPDFRegular(ch):
+			start := i
+			for i < len(c) && isPDFRegular(c[i]) {
+				i++
+			}
+			word := string(c[start:i])
+			// Large negative kerning inside TJ array separates words
+			if n, err := strconv.ParseFloat(word, 64); err == nil {
+				if inArray && inText && n < -200 {
+					shown = append(shown, " ")
+				}
+				continue
+			}
+			switch word {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				out.WriteByte('\n')
+			case "Tj", "TJ", "'", "\"":
+				for _, s := range shown {
+					out.WriteString(s)
+				}
+			case "Td", "TD", "T*", "Tm":
+				out.WriteByte(' ')
+			}
+			if !strings.HasPrefix(word, "/") {
+				shown = shown[:0]
+			}
+		default:
+			i++
+		}
+	}
+}
+
+// Reports whether ch is part of names, numbers and operators
+func isPDFRegular(ch byte) bool {
+	switch ch {
+	case ' ', '\t', '\r', '\n', '\f', 0, '(', ')', '<', '>', '[', ']', '{', '}', '%':
+		return false
+	}
+	return true
+}
+
+// Decodes literal string at start of c, returns it
+// with number of bytes it takes
+func literalString(c []byte) (string, int) {
+	var b []byte
+	depth := 0
+	i := 0
+	for ; i < len(c); i++ {
+		ch := c[i]
+		switch ch {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return decodeText(b), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(c) {
+				break
+			}
+			switch e := c[i]; e {
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation
+				if e == '\r' && i+1 < len(c) && c[i+1] == '\n' {
+					i++
+				}
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				// Up to three octal digits
+				n := 0
+				for j := 0; j < 3 && i < len(c) && c[i] >= '0' && c[i] <= '7'; j++ {
+					n = n*8 + int(c[i]-'0')
+					i++
+				}
+				i--
+				b = append(b, byte(n))
+			default:
+				b = append(b, e)
+			}
+			continue
+		}
+		b = append(b, ch)
+	}
+	return decodeText(b), i
+}
+
+// Decodes hex string at start of c, returns it
+// with number of bytes it takes
+func hexString(c []byte) (string, int) {
+	end := bytes.IndexByte(c, '>')
+	if end < 0 {
+		return "", len(c)
+	}
+	var digits []byte
+	for _, ch := range c[1:end] {
+		if strings.IndexByte("0123456789abcdefABCDEF", ch) >= 0 {
+			digits = append(digits, ch)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	b := make([]byte, len(digits)/2)
+	for i := range b {
+		n, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		b[i] = byte(n)
+	}
+	// Glyph ids of fonts with custom encoding are not text
+	text := decodeText(b)
+	for _, r := range text {
+		if r < ' ' && r != '\n' && r != '\r' && r != '\t' {
+			return "", end + 1
+		}
+	}
+	return text, end + 1
+}
+
+// Decodes UTF-16 string with byte order mark, other
+// strings are taken as Latin-1 close to PDFDocEncoding
+func decodeText(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	r := make([]rune, len(b))
+	for i, ch := range b {
+		r[i] = rune(ch)
+	}
+	return string(r)
+}
@@ -0,0 +1,146 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Builds PDF with single object holding stream of content under dict
+func pdfWithStream(dict string, content []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	fmt.Fprintf(&b, "4 0 obj\n<< /Length %d %s >>\nstream\n", len(content), dict)
+	b.Write(content)
+	b.WriteString("\nendstream\nendobj\n%%EOF\n")
+	return b.Bytes()
+}
+
+// Compresses content with zlib as FlateDecode filter does
+func flate(content string) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write([]byte(content))
+	w.Close()
+	return b.Bytes()
+}
+
+func TestPDFText(t *testing.T) {
+	tests := []struct {
+		name string
+		pdf  []byte
+		want string
+	}{
+		{
+			name: "show string",
+			pdf:  pdfWithStream("", []byte("BT /F1 12 Tf 72 712 Td (Hello) Tj ET")),
+			want: " Hello\n",
+		},
+		{
+			name: "text outside of BT is skipped",
+			pdf:  pdfWithStream("", []byte("(hidden) Tj BT (shown) Tj ET")),
+			want: "shown\n",
+		},
+		{
+			name: "TJ kerning",
+			pdf:  pdfWithStream("", []byte("BT [(Hel) -20 (lo) -300 (World) 120 (!)] TJ ET")),
+			want: "Hello World!\n",
+		},
+		{
+			name: "octal escapes",
+			pdf:  pdfWithStream("", []byte(`BT (caf\351 \101\102C \7x \0534) Tj ET`)),
+			want: "café ABC \ax +4\n",
+		},
+		{
+			name: "escapes and nested parentheses",
+			pdf: pdfWithStream("", []byte(`BT (a\(b\) (c) d\\e\
+f) Tj ET`)),
+			want: `a(b) (c) d\ef` + "\n",
+		},
+		{
+			name: "UTF-16 literal with BOM",
+			pdf:  pdfWithStream("", []byte(`BT (\376\377\000H\000\351\004\037) Tj ET`)),
+			want: "HéП\n",
+		},
+		{
+			name: "UTF-16 hex with BOM",
+			pdf:  pdfWithStream("", []byte("BT <FEFF 0048 00E9 D83D DE00> Tj ET")),
+			want: "Hé😀\n",
+		},
+		{
+			name: "hex glyph ids are skipped",
+			pdf:  pdfWithStream("", []byte("BT <00010002> Tj (text) Tj ET")),
+			want: "text\n",
+		},
+		{
+			name: "flate stream",
+			pdf:  pdfWithStream("/Filter /FlateDecode", flate("BT (deflated) Tj ET")),
+			want: "deflated\n",
+		},
+		{
+			name: "unsupported filter",
+			pdf:  pdfWithStream("/Filter /DCTDecode", []byte("BT (jpeg) Tj ET")),
+			want: "",
+		},
+		{
+			name: "font program",
+			pdf:  pdfWithStream("/Length1 100", []byte("BT (glyphs) Tj ET")),
+			want: "",
+		},
+		{
+			name: "missing endstream",
+			pdf:  []byte("1 0 obj\n<< >>\nstream\nBT (cut) Tj ET"),
+			want: "",
+		},
+		{
+			name: "unterminated string",
+			pdf:  pdfWithStream("", []byte("BT (never closed Tj ET")),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pdfText(tt.pdf); got != tt.want {
+				t.Errorf("pdfText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFTextTruncatedStream(t *testing.T) {
+	// Long text, so its compressed stream can be cut in the middle
+	var content strings.Builder
+	content.WriteString("BT ")
+	for i := range 200 {
+		fmt.Fprintf(&content, "(line %03d) Tj T* ", i)
+	}
+	content.WriteString("ET")
+	compressed := flate(content.String())
+
+	// Decoded start of stream is used
+	got := pdfText(pdfWithStream("/Filter /FlateDecode", compressed[:len(compressed)/2]))
+	if !strings.HasPrefix(got, "line 000 line 001 ") {
+		t.Fatalf("pdfText() = %q, want text of decoded start", got)
+	}
+	if strings.Contains(got, "line 199") {
+		t.Fatalf("pdfText() = %q, has text of cut end", got)
+	}
+}
+
+func FuzzPDF(f *testing.F) {
+	f.Add(pdfWithStream("", []byte("BT [(a) -300 (b)] TJ ET")))
+	f.Add(pdfWithStream("", []byte(`BT (\376\377\000a) Tj (\1234) Tj ET`)))
+	f.Add(pdfWithStream("", []byte("BT <FEFFD83D> Tj <0> Tj ET")))
+	f.Add(pdfWithStream("/Filter /FlateDecode", flate("BT (z) Tj ET")))
+	f.Add([]byte("stream\n(\\"))
+	f.Add([]byte("stream\n<<endstream"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		text := pdfText(data)
+		if !utf8.ValidString(text) {
+			t.Fatalf("pdfText() = %q, not valid UTF-8", text)
+		}
+	})
+}
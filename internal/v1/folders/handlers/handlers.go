@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"docshell/internal/v1/folders/models"
+	"docshell/internal/v1/folders/service"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Maximum size of request body
+const maxBodySize = 1 << 20
+
+// Handler serves folders
+type Handler struct {
+	service *service.Service
+}
+
+func New(service *service.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetRootFolder(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ListDocuments) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetFolder(ctx, w, r, 0)
+}
+
+func (h *Handler) GetFolder(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r, "id")
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ListDocuments) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetFolder(ctx, w, r, id)
+}
+
+func (h *Handler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.CreateDocument) {
+		return
+	}
+	// Struct to put in it parsed body
+	var fc models.FolderCreation
+	if !readBody(w, r, &fc) {
+		return
+	}
+	// Call next function and pass context
+	h.service.CreateFolder(ctx, w, r, fc)
+}
+
+func (h *Handler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r, "id")
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.UpdateDocument) {
+		return
+	}
+	// Struct to put in it parsed body
+	var fu models.FolderUpdate
+	if !readBody(w, r, &fu) {
+		return
+	}
+	if fu == (models.FolderUpdate{}) {
+		msg := "Nothing to update"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if fu.ParentId != nil && 0 > *fu.ParentId {
+		msg := fmt.Sprintf("Field 'parent_id=%v' incorrect", *fu.ParentId)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Call next function and pass context
+	h.service.UpdateFolder(ctx, w, r, id, fu)
+}
+
+func (h *Handler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r, "id")
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.DeleteDocument) {
+		return
+	}
+	// Call next function and pass context
+	h.service.DeleteFolder(ctx, w, r, id)
+}
+
+// Reads positive integer path value, sends error response if incorrect
+func readId(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value '%v=%v' incorrect", name, r.PathValue(name))
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, false
+	}
+	return id, true
+}
+
+// Decodes JSON body into v, sends error response if incorrect
+func readBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	// Read body
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		msg := "Body can not be read"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	if len(body) == 0 {
+		msg := "Body is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	// Try to decode body into the struct
+	if err := json.Unmarshal(body, v); err != nil {
+		msg := "JSON is incorrect"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package models
+
+import docModels "docshell/internal/v1/docs/models"
+
+// Folder documents are kept in, documents in folder have its path
+type Folder struct {
+	Id int64 `json:"id" db:"id"`
+	// Nil for folders in the root
+	ParentId *int64 `json:"parent_id" db:"parent_id"`
+	Name     string `json:"name" db:"name"`
+	// Names of folder and its parents joined by '/'
+	Path string `json:"path" db:"path"`
+	// Nil for folders created for paths of documents
+	OwnerId   *int64 `json:"owner_id" db:"owner_id"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+	ChangedAt string `json:"changed_at" db:"changed_at"`
+}
+
+// Folder is created in the root if ParentId is nil
+type FolderCreation struct {
+	ParentId *int64 `json:"parent_id"`
+	Name     string `json:"name"`
+}
+
+// Fields left nil are not changed, ParentId 0 moves folder into the root
+type FolderUpdate struct {
+	ParentId *int64  `json:"parent_id"`
+	Name     *string `json:"name"`
+}
+
+type ResponseSingleFolder struct {
+	StatusCode int    `json:"status_code"`
+	Folder     Folder `json:"folder"`
+}
+
+// Folder with its children, Folder is nil for the root
+type ResponseFolderChildren struct {
+	StatusCode int                  `json:"status_code"`
+	Folder     *Folder              `json:"folder"`
+	Folders    []Folder             `json:"folders"`
+	Documents  []docModels.Document `json:"documents"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "database/sql"
+
+func ScanFolder(rows *sql.Rows) (Folder, error) {
+	f := Folder{}
+	if err := rows.Scan(&f.Id, &f.ParentId, &f.Name, &f.Path,
+		&f.OwnerId, &f.CreatedAt, &f.ChangedAt); err != nil {
+		return Folder{}, err
+	}
+	return f, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+const (
+	folder_columns = "id, parent_id, name, path, owner_id, created_at, changed_at"
+
+	// Groups of user $2
+	user_groups = "select m.group_id from group_members m where m.user_id = $2"
+	// Folder f is visible to user $2 if they own it, have grant on it,
+	// on folder containing it or inside it, or see document inside it
+	folder_visible = `
+		f.owner_id = $2
+		or exists (
+			select 1 from grants g
+				where (g.user_id = $2 or g.group_id in (` + user_groups + `))
+					and (g.path = '.' or g.path = f.path or starts_with(f.path, g.path || '/')
+						or starts_with(g.path, f.path || '/'))
+		)
+		or exists (
+			select 1 from documents d
+				where d.deleted_at is null
+					and (d.path = f.path or starts_with(d.path, f.path || '/'))
+					and (d.uploader_id = $2 or exists (
+						select 1 from grants g
+							where (g.user_id = $2 or g.group_id in (` + user_groups + `))
+								and g.document_id = d.id
+					))
+		)
+	`
+
+	// Folder $3 if user $2 can see it, any folder if $1 is true
+	get_visible_folder = "select " + folder_columns + ` from folders f
+		where f.id = $3 and ($1 or ` + folder_visible + `);
+	`
+	// Folders in folder $3, in the root if it is null
+	get_child_folders = "select " + folder_columns + ` from folders f
+		where f.parent_id is not distinct from $3 and ($1 or ` + folder_visible + `)
+		order by f.name;
+	`
+	// Permission of user $2 on folder path $1, owners
+	// of folder or folder containing it may write
+	get_folder_permission = `
+		select case
+				when exists (
+					select 1 from folders f
+						where f.owner_id = $2 and (f.path = $1 or starts_with($1, f.path || '/'))
+				) or 'write' in (` + path_grants + `) then 'write'
+				when exists (` + path_grants + `) then 'read'
+				else ''
+			end;
+	`
+	// Permissions granted to user $2 on path $1 or folder containing it
+	path_grants = `
+		select g.permission from grants g
+			where (g.user_id = $2 or g.group_id in (` + user_groups + `))
+				and (g.path = '.' or g.path = $1 or starts_with($1, g.path || '/'))
+	`
+
+	insert_folder = `
+		insert into folders (parent_id, name, path, owner_id)
+			values ($1, $2, $3, $4)
+			returning ` + folder_columns + `;
+	`
+	// Creates folder $3 named $2 in folder with path $1 unless it exists,
+	// it has no owner since it only holds documents saved with its path
+	ensure_folder = `
+		insert into folders (parent_id, name, path)
+			values ((select id from folders where path = $1), $2, $3)
+			on conflict (path) do nothing;
+	`
+
+	// Moving
+	lock_folder = "select " + folder_columns + " from folders where id = $1 for update;"
+	move_folder = `
+		update folders set parent_id = $2, name = $3, path = $4, changed_at = now()
+			where id = $1
+			returning ` + folder_columns + `;
+	`
+	// Folder with path $1 contains document, in trash too, user $2 may not
+	// change. Uploaders and users granted write on document may change it.
+	folder_has_locked_documents = `
+		select exists (
+			select 1 from documents d
+				where (d.path = $1 or starts_with(d.path, $1 || '/'))
+					and d.uploader_id is distinct from $2
+					and 'write' not in (select permission from document_grants($2, d.id, d.path))
+		);
+	`
+	// Replace prefix $1 of paths under it with $2
+	move_subfolders = `
+		update folders set path = $2 || substr(path, length($1) + 1)
+			where starts_with(path, $1 || '/');
+	`
+	move_folder_documents = `
+		update documents set path = $2 || substr(path, length($1) + 1)
+			where path = $1 or starts_with(path, $1 || '/');
+	`
+	move_folder_grants = `
+		update grants set path = $2 || substr(path, length($1) + 1)
+			where path = $1 or starts_with(path, $1 || '/');
+	`
+
+	// Deleting
+	// Folder $1 with path $2 contains folders or documents not in trash
+	folder_has_children = `
+		select exists (select 1 from folders where parent_id = $1)
+			or exists (
+				select 1 from documents
+					where deleted_at is null
+						and (path = $2 or starts_with(path, $2 || '/'))
+			);
+	`
+	delete_folder_grants = "delete from grants where path = $1 or starts_with(path, $1 || '/');"
+	delete_folder        = "delete from folders where id = $1 returning " + folder_columns + ";"
+)
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/folders/models"
+	"docshell/internal/v1/storage"
+	"errors"
+	"path"
+	"strings"
+)
+
+var (
+	ErrFolderNotEmpty = errors.New("folder is not empty")
+	ErrFolderCycle    = errors.New("folder can not be moved into itself")
+	ErrFolderLocked   = errors.New("folder contains documents user may not change")
+)
+
+// GetVisibleFolder returns folder if user can see it,
+// any folder is visible if all is true
+func GetVisibleFolder(ctx context.Context, con *sql.DB, id, userId int64, all bool) (models.Folder, error) {
+	return queryFolder(ctx, con, get_visible_folder, all, userId, id)
+}
+
+// GetChildFolders returns folders in parent visible to user,
+// folders in the root if parent is nil
+func GetChildFolders(ctx context.Context, con *sql.DB, parentId *int64, userId int64, all bool) ([]models.Folder, error) {
+	rows, err := con.QueryContext(ctx, get_child_folders, all, userId, parentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanFolder)
+}
+
+// GetFolderPermission returns 'read' or 'write' permission of user
+// on folder path, or empty string if it has none
+func GetFolderPermission(ctx context.Context, con *sql.DB, path string, userId int64) (string, error) {
+	var permission string
+	err := con.QueryRowContext(ctx, get_folder_permission, path, userId).Scan(&permission)
+	return permission, err
+}
+
+// CreateFolder creates folder named name in parent with path
+// parentPath, parentId is nil for the root
+func CreateFolder(ctx context.Context, con *sql.DB, parentId *int64, parentPath, name string, ownerId int64) (models.Folder, error) {
+	return queryFolder(ctx, con, insert_folder, parentId, name, joinPath(parentPath, name), ownerId)
+}
+
+// EnsureFolders creates folder p with all folders containing it,
+// existing folders are kept. Created folders have no owner.
+func EnsureFolders(ctx context.Context, con storage.Executor, p string) error {
+	if p == "." {
+		return nil
+	}
+	// Parents are created first
+	names := strings.Split(p, "/")
+	for i := range names {
+		current := strings.Join(names[:i+1], "/")
+		if _, err := con.ExecContext(ctx, ensure_folder,
+			path.Dir(current), names[i], current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveFolder renames folder and moves it into parent, the root if
+// parentId is nil. Paths of folders, documents and grants inside it
+// are changed with it. Content of documents is stored by hash,
+// so files in the volume are not moved. Unless all is true user must
+// be allowed to change every document inside, ErrFolderLocked is
+// returned otherwise.
+func MoveFolder(ctx context.Context, con *sql.DB, id int64, parentId *int64, name string,
+	userId int64, all bool) (models.Folder, error) {
+	tx, err := con.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Folder{}, err
+	}
+	defer tx.Rollback()
+
+	// Lock folder and new parent, they may be moved meanwhile
+	folder, err := lockFolder(ctx, tx, id)
+	if err != nil || folder == (models.Folder{}) {
+		return folder, err
+	}
+	parentPath := "."
+	if parentId != nil {
+		parent, err := lockFolder(ctx, tx, *parentId)
+		if err != nil || parent == (models.Folder{}) {
+			return models.Folder{}, err
+		}
+		// Folder can not contain itself
+		if parent.Path == folder.Path || strings.HasPrefix(parent.Path, folder.Path+"/") {
+			return models.Folder{}, ErrFolderCycle
+		}
+		parentPath = parent.Path
+	}
+	newPath := joinPath(parentPath, name)
+
+	// Paths of documents are changed, so user must be allowed to change them
+	if !all {
+		var locked bool
+		if err := tx.QueryRowContext(ctx, folder_has_locked_documents, folder.Path, userId).Scan(&locked); err != nil {
+			return models.Folder{}, err
+		}
+		if locked {
+			return models.Folder{}, ErrFolderLocked
+		}
+	}
+
+	// Update folder, then everything under its path
+	rows, err := tx.QueryContext(ctx, move_folder, id, parentId, name, newPath)
+	if err != nil {
+		return models.Folder{}, err
+	}
+	moved, err := storage.ScanSingle(rows, models.ScanFolder)
+	rows.Close()
+	if err != nil {
+		return models.Folder{}, err
+	}
+	for _, query := range []string{move_subfolders, move_folder_documents, move_folder_grants} {
+		if _, err := tx.ExecContext(ctx, query, folder.Path, newPath); err != nil {
+			return models.Folder{}, err
+		}
+	}
+
+	return moved, tx.Commit()
+}
+
+// DeleteFolder removes empty folder with grants given on it and returns
+// it, ErrFolderNotEmpty is returned if it contains folders or documents
+func DeleteFolder(ctx context.Context, con *sql.DB, id int64) (models.Folder, error) {
+	tx, err := con.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Folder{}, err
+	}
+	defer tx.Rollback()
+
+	// Lock folder, children can not be moved in meanwhile
+	folder, err := lockFolder(ctx, tx, id)
+	if err != nil || folder == (models.Folder{}) {
+		return folder, err
+	}
+
+	var notEmpty bool
+	if err := tx.QueryRowContext(ctx, folder_has_children, id, folder.Path).Scan(&notEmpty); err != nil {
+		return models.Folder{}, err
+	}
+	if notEmpty {
+		return models.Folder{}, ErrFolderNotEmpty
+	}
+
+	if _, err := tx.ExecContext(ctx, delete_folder_grants, folder.Path); err != nil {
+		return models.Folder{}, err
+	}
+	rows, err := tx.QueryContext(ctx, delete_folder, id)
+	if err != nil {
+		return models.Folder{}, err
+	}
+	deleted, err := storage.ScanSingle(rows, models.ScanFolder)
+	rows.Close()
+	if err != nil {
+		return models.Folder{}, err
+	}
+
+	return deleted, tx.Commit()
+}
+
+// Locks folder till end of transaction, empty folder is returned if it is not found
+func lockFolder(ctx context.Context, tx *sql.Tx, id int64) (models.Folder, error) {
+	rows, err := tx.QueryContext(ctx, lock_folder, id)
+	if err != nil {
+		return models.Folder{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanFolder)
+}
+
+// Runs query returning single folder
+func queryFolder(ctx context.Context, con *sql.DB, query string, args ...any) (models.Folder, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.Folder{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanFolder)
+}
+
+// Returns path of folder named name in folder with parentPath
+func joinPath(parentPath, name string) string {
+	if parentPath == "." {
+		return name
+	}
+	return parentPath + "/" + name
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	docModels "docshell/internal/v1/docs/models"
+	docRepository "docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/folders/models"
+	"docshell/internal/v1/folders/repository"
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/storage"
+	"docshell/internal/v1/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Service manages folders documents are kept in
+type Service struct {
+	// Database of folders
+	db *sql.DB
+	// Documents inside folders
+	documents docRepository.DocumentRepository
+}
+
+func New(db *sql.DB, documents docRepository.DocumentRepository) *Service {
+	return &Service{db: db, documents: documents}
+}
+
+// GetFolder sends folder with folders and documents in it,
+// content of the root if id is 0
+func (s *Service) GetFolder(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	user, _ := auth.UserFromContext(ctx)
+	all := policy.Can(user, policy.AccessAllDocuments)
+
+	// Get folder unless root is requested
+	res := models.ResponseFolderChildren{StatusCode: http.StatusOK}
+	var parentId *int64
+	path := "."
+	if id != 0 {
+		folder, ok := getVisibleFolder(ctx, w, con, id)
+		if !ok {
+			return
+		}
+		res.Folder = &folder
+		parentId = &folder.Id
+		path = folder.Path
+	}
+
+	// Get children visible to user
+	folders, err := repository.GetChildFolders(ctx, con, parentId, user.Id, all)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not read folders"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	docs, err := s.documents.GetFolderDocuments(ctx, user.Id, all, path)
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not read docs"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res.Folders = make([]models.Folder, len(folders))
+	copy(res.Folders, folders)
+	res.Documents = make([]docModels.Document, len(docs))
+	copy(res.Documents, docs)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) CreateFolder(ctx context.Context, w http.ResponseWriter, r *http.Request, fc models.FolderCreation) {
+	// Validate fields
+	fc.Name = strings.TrimSpace(fc.Name)
+	if !validName(fc.Name) {
+		msg := fmt.Sprintf("Name '%v' incorrect", fc.Name)
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check user may write into parent
+	parentPath, ok := getParentPath(ctx, w, con, fc.ParentId)
+	if !ok {
+		return
+	}
+
+	// Save folder
+	user, _ := auth.UserFromContext(ctx)
+	folder, err := repository.CreateFolder(ctx, con, fc.ParentId, parentPath, fc.Name, user.Id)
+	if err != nil {
+		sendFolderError(w, err, "Database error: could not save folder")
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleFolder{
+		StatusCode: http.StatusOK,
+		Folder:     folder,
+	})
+}
+
+// UpdateFolder renames folder and moves it with everything inside it
+func (s *Service) UpdateFolder(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64, fu models.FolderUpdate) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check user may change folder
+	folder, ok := getVisibleFolder(ctx, w, con, id)
+	if !ok || !policy.AuthorizeFolder(ctx, w, con, folder.Path, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Apply changed fields over current ones
+	parentId, name := folder.ParentId, folder.Name
+	if fu.Name != nil {
+		name = strings.TrimSpace(*fu.Name)
+		if !validName(name) {
+			msg := fmt.Sprintf("Name '%v' incorrect", name)
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+	if fu.ParentId != nil {
+		parentId = fu.ParentId
+		if *parentId == 0 {
+			parentId = nil
+		}
+		// Check user may write into new parent
+		if _, ok := getParentPath(ctx, w, con, parentId); !ok {
+			return
+		}
+	}
+
+	// Move folder with its content
+	user, _ := auth.UserFromContext(ctx)
+	moved, err := repository.MoveFolder(ctx, con, id, parentId, name,
+		user.Id, policy.Can(user, policy.AccessAllDocuments))
+	if errors.Is(err, repository.ErrFolderCycle) {
+		msg := "Folder can not be moved into itself"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	if errors.Is(err, repository.ErrFolderLocked) {
+		msg := "Folder contains documents you may not change"
+		utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+		return
+	}
+	if err != nil {
+		sendFolderError(w, err, "Database error: could not move folder")
+		return
+	}
+	if moved == (models.Folder{}) {
+		msg := "Requested folder not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleFolder{
+		StatusCode: http.StatusOK,
+		Folder:     moved,
+	})
+}
+
+// DeleteFolder removes folder without folders and documents in it
+func (s *Service) DeleteFolder(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Check user may delete folder
+	folder, ok := getVisibleFolder(ctx, w, con, id)
+	if !ok || !policy.AuthorizeFolder(ctx, w, con, folder.Path, aclModels.PermissionWrite) {
+		return
+	}
+
+	// Delete folder
+	deleted, err := repository.DeleteFolder(ctx, con, id)
+	if errors.Is(err, repository.ErrFolderNotEmpty) {
+		msg := "Folder contains folders or documents"
+		utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		msg := "Database error: could not delete folder"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if deleted == (models.Folder{}) {
+		msg := "Requested folder not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleFolder{
+		StatusCode: http.StatusOK,
+		Folder:     deleted,
+	})
+}
+
+// EnsureDocumentFolders creates folders for paths of documents
+// saved before folders existed
+func (s *Service) EnsureDocumentFolders(ctx context.Context) error {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	paths, err := s.documents.GetDocumentPaths(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := repository.EnsureFolders(ctx, s.db, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gets folder visible to user, sends error response if there is none
+func getVisibleFolder(ctx context.Context, w http.ResponseWriter, con *sql.DB, id int64) (models.Folder, bool) {
+	user, _ := auth.UserFromContext(ctx)
+	folder, err := repository.GetVisibleFolder(ctx, con, id, user.Id, policy.Can(user, policy.AccessAllDocuments))
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return models.Folder{}, false
+	}
+	if folder == (models.Folder{}) {
+		msg := "Requested folder not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return models.Folder{}, false
+	}
+	return folder, true
+}
+
+// Returns path of parent folder user may write into,
+// the root if parentId is nil
+func getParentPath(ctx context.Context, w http.ResponseWriter, con *sql.DB, parentId *int64) (string, bool) {
+	if parentId == nil {
+		return ".", true
+	}
+	parent, ok := getVisibleFolder(ctx, w, con, *parentId)
+	if !ok || !policy.AuthorizeFolder(ctx, w, con, parent.Path, aclModels.PermissionWrite) {
+		return "", false
+	}
+	return parent.Path, true
+}
+
+// Reports whether name can be used as folder name
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+// Sends response matching repository error of folder saving
+func sendFolderError(w http.ResponseWriter, err error, msg string) {
+	// Path identifies folder, path and title identify document
+	if storage.IsUniqueViolation(err) {
+		msg := "Folder or document with such path already exists"
+		utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+		return
+	}
+	log.Println(err)
+	utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+}
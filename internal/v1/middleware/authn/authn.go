@@ -0,0 +1,86 @@
+package authn
+
+import (
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/auth/service"
+	"docshell/internal/v1/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// AuthMiddleware authenticates request by bearer access token
+// or API key and puts its user into request context
+func AuthMiddleware(svc *service.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Read token from 'Authorization: Bearer <token>'
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="docshell"`)
+				msg := "Authorization required"
+				utils.SendJSONErrorResponse(w, http.StatusUnauthorized, msg)
+				return
+			}
+
+			// API keys are limited to their scopes
+			if auth.IsApiKey(token) {
+				user, scopes, err := svc.AuthenticateApiKey(r.Context(), token)
+				if err == service.ErrInvalidApiKey {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="docshell", error="invalid_token"`)
+					msg := "API key incorrect, expired or revoked"
+					utils.SendJSONErrorResponse(w, http.StatusUnauthorized, msg)
+					return
+				}
+				if err != nil {
+					log.Println(err)
+					msg := "Internal server error"
+					utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+					return
+				}
+
+				ctx := auth.WithScopes(auth.WithUser(r.Context(), user, 0), scopes)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			user, sessionId, err := svc.Authenticate(r.Context(), token)
+			if err == auth.ErrInvalidToken || err == service.ErrSessionEnded {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="docshell", error="invalid_token"`)
+				msg := "Access token incorrect, expired or revoked"
+				utils.SendJSONErrorResponse(w, http.StatusUnauthorized, msg)
+				return
+			}
+			if err != nil {
+				log.Println(err)
+				msg := "Internal server error"
+				utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user, sessionId)))
+		})
+	}
+}
+
+// ScopeMiddleware rejects requests made with API key lacking scope,
+// safe methods need read scope and others need write scope.
+// It must follow AuthMiddleware.
+func ScopeMiddleware(read, write string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope := write
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				scope = read
+			}
+			if !auth.HasScope(r.Context(), scope) {
+				msg := fmt.Sprintf("API key lacks scope '%v'", scope)
+				utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package presign
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/users/models"
+	"docshell/internal/v1/users/repository"
+	"docshell/internal/v1/utils"
+	"log"
+	"net/http"
+	"time"
+)
+
+// PresignMiddleware authenticates request by signature of pre-signed URL
+// and puts user who signed it into request context. Signature covers
+// method, path and query, so URL allows only request it was made for.
+// Signers are looked up in db.
+func PresignMiddleware(signer *auth.Signer, db *sql.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId, err := signer.VerifyPresigned(r.Method, r.URL, time.Now())
+			if err == auth.ErrExpiredSignature {
+				msg := "Pre-signed URL expired"
+				utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+				return
+			}
+			if err != nil {
+				msg := "Pre-signed URL signature incorrect"
+				utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+				return
+			}
+
+			// Set timeout context
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			defer cancel()
+
+			// Signer must still exist, its role is checked by handlers
+			user, err := repository.GetUserById(ctx, db, userId)
+			if err != nil {
+				log.Println(err)
+				msg := "Internal server error"
+				utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+				return
+			}
+			if user == (models.User{}) {
+				msg := "Pre-signed URL signature incorrect"
+				utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user, 0)))
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// Package migrations keeps database schema up to date with numbered
+// SQL files embedded into the binary. File 'NNNN_name.up.sql' applies
+// migration NNNN and 'NNNN_name.down.sql' reverts it.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Key of advisory lock held while migrating, "docshell" in ASCII
+const lockKey int64 = 7237112473531542636
+
+// Numbered change of schema
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// Migration with time it was applied, nil if it is pending
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Up applies all pending migrations in order and returns them
+func Up(ctx context.Context, con *sql.DB) ([]Migration, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withLock(ctx, con, func(conn *sql.Conn) error {
+		applied, err := getApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, m.up, insert_migration, m.Version, m.Name); err != nil {
+				return fmt.Errorf("migration %v up: %w", m, err)
+			}
+			log.Printf("Applied migration %v", m)
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts steps last applied migrations and returns them
+func Down(ctx context.Context, con *sql.DB, steps int) ([]Migration, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	var done []Migration
+	err = withLock(ctx, con, func(conn *sql.Conn) error {
+		applied, err := getApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		// Latest migrations are reverted first
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, v := range versions[:min(steps, len(versions))] {
+			m, ok := known[v]
+			if !ok {
+				return fmt.Errorf("migration %04d is applied, but not known to this build", v)
+			}
+			if err := run(ctx, conn, m.down, delete_migration, m.Version); err != nil {
+				return fmt.Errorf("migration %v down: %w", m, err)
+			}
+			log.Printf("Reverted migration %v", m)
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns every known migration with time it was applied
+func Status(ctx context.Context, con *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	err = withLock(ctx, con, func(conn *sql.Conn) error {
+		applied, err := getApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			s := MigrationStatus{Version: m.Version, Name: m.Name}
+			if at, ok := applied[m.Version]; ok {
+				s.AppliedAt = &at
+			}
+			statuses = append(statuses, s)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Runs migration script and records it in one transaction
+func run(ctx context.Context, conn *sql.Conn, script, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Script without arguments may hold many statements
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Calls fn on connection holding migration lock,
+// so concurrent runs wait for each other
+func withLock(ctx context.Context, con *sql.DB, fn func(conn *sql.Conn) error) error {
+	// Advisory lock belongs to session, so single connection is used
+	conn, err := con.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, lock_migrations, lockKey); err != nil {
+		return err
+	}
+	defer func() {
+		// Unlocked even if ctx is done
+		if _, err := conn.ExecContext(context.Background(), unlock_migrations, lockKey); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, create_migrations_table); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// Returns versions of applied migrations with time they were applied
+func getApplied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, get_applied_migrations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var name string
+		var at time.Time
+		if err := rows.Scan(&version, &name, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// Reads migrations in directory "sql" of fsys ordered by version
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		// NNNN_name.up.sql or NNNN_name.down.sql
+		base, ok := strings.CutSuffix(e.Name(), ".sql")
+		if !ok {
+			continue
+		}
+		base, direction := strings.TrimSuffix(base, path.Ext(base)), strings.TrimPrefix(path.Ext(base), ".")
+		number, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(number, 10, 64)
+		if !ok || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration file name %q incorrect", e.Name())
+		}
+
+		script, err := fs.ReadFile(fsys, path.Join("sql", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %04d has names %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.up = string(script)
+		} else {
+			m.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %v needs both up and down files", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("load() found no migrations")
+	}
+	// Versions are numbered without gaps, so a missing file is noticed
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %v has version %d, want %d", m, m.Version, i+1)
+		}
+		if strings.TrimSpace(m.up) == "" || strings.TrimSpace(m.down) == "" {
+			t.Errorf("migration %v has empty script", m)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := func(script string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(script)}
+	}
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  []string
+		err   string
+	}{
+		{
+			name: "ordered by number",
+			files: fstest.MapFS{
+				"sql/10_later.up.sql":         file("up 10"),
+				"sql/10_later.down.sql":       file("down 10"),
+				"sql/2_first.up.sql":          file("up 2"),
+				"sql/2_first.down.sql":        file("down 2"),
+				"sql/0003_with_name.up.sql":   file("up 3"),
+				"sql/0003_with_name.down.sql": file("down 3"),
+				"sql/README.md":               file("not a migration"),
+			},
+			want: []string{"0002_first", "0003_with_name", "0010_later"},
+		},
+		{
+			name:  "missing down",
+			files: fstest.MapFS{"sql/0001_users.up.sql": file("up")},
+			err:   "needs both up and down files",
+		},
+		{
+			name: "different names",
+			files: fstest.MapFS{
+				"sql/0001_users.up.sql":    file("up"),
+				"sql/0001_people.down.sql": file("down"),
+			},
+			err: `has names`,
+		},
+		{
+			name:  "no number",
+			files: fstest.MapFS{"sql/users.up.sql": file("up")},
+			err:   "incorrect",
+		},
+		{
+			name:  "unknown direction",
+			files: fstest.MapFS{"sql/0001_users.sideways.sql": file("up")},
+			err:   "incorrect",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.files)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("load() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range migrations {
+				got = append(got, m.String())
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Fatalf("load() = %v, want %v", got, tt.want)
+			}
+			if migrations[0].up != "up 2" || migrations[0].down != "down 2" {
+				t.Fatalf("scripts of %v = %q, %q", migrations[0], migrations[0].up, migrations[0].down)
+			}
+		})
+	}
+}
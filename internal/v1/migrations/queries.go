@@ -0,0 +1,18 @@
+package migrations
+
+const (
+	create_migrations_table = `
+		create table if not exists schema_migrations (
+			version    bigint      primary key,
+			name       text        not null,
+			applied_at timestamptz not null default now()
+		);
+	`
+	get_applied_migrations = "select version, name, applied_at from schema_migrations order by version;"
+	insert_migration       = "insert into schema_migrations (version, name) values ($1, $2);"
+	delete_migration       = "delete from schema_migrations where version = $1;"
+
+	// Session lock, released explicitly or when connection closes
+	lock_migrations   = "select pg_advisory_lock($1);"
+	unlock_migrations = "select pg_advisory_unlock($1);"
+)
@@ -0,0 +1,12 @@
+-- Accounts of people using the service
+create table if not exists users (
+	id            bigserial   primary key,
+	name          text        not null,
+	email         text        not null unique,
+	-- PBKDF2 hash made by util.HashPassword
+	password_hash text        not null,
+	role          text        not null default 'viewer'
+		check (role in ('viewer', 'editor', 'admin')),
+	created_at    timestamptz not null default now(),
+	changed_at    timestamptz not null default now()
+);
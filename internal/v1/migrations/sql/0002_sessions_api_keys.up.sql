@@ -0,0 +1,31 @@
+-- Login sessions, access tokens are valid while their session is active
+create table if not exists sessions (
+	id           bigserial   primary key,
+	user_id      bigint      not null references users (id) on delete cascade,
+	-- SHA-256 of current refresh token
+	refresh_hash text        not null unique,
+	created_at   timestamptz not null default now(),
+	expires_at   timestamptz not null,
+	revoked_at   timestamptz
+);
+
+create index if not exists sessions_user_idx on sessions (user_id);
+
+-- Keys for access without user session, limited to scopes
+create table if not exists api_keys (
+	id           bigserial   primary key,
+	user_id      bigint      not null references users (id) on delete cascade,
+	name         text        not null,
+	-- Leading characters of key to recognize it
+	prefix       text        not null,
+	-- SHA-256 of key
+	key_hash     text        not null unique,
+	scopes       text[]      not null,
+	-- Never expires if null
+	expires_at   timestamptz,
+	last_used_at timestamptz,
+	created_at   timestamptz not null default now(),
+	revoked_at   timestamptz
+);
+
+create index if not exists api_keys_user_idx on api_keys (user_id);
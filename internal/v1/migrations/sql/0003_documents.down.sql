@@ -0,0 +1,4 @@
+drop table if exists legacy_files;
+drop table if exists document_versions;
+drop table if exists documents;
+drop table if exists blobs;
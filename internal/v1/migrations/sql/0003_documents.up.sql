@@ -0,0 +1,80 @@
+-- Content addressed files stored in the volume under their hash,
+-- every document and version holds one reference
+create table if not exists blobs (
+	hash       text        primary key,
+	size       bigint      not null,
+	ref_count  integer     not null default 0,
+	created_at timestamptz not null default now()
+);
+
+create index if not exists blobs_unreferenced_idx
+	on blobs (hash) where ref_count <= 0;
+
+-- Environments set up by hand before migrations have documents table
+-- with columns of the original queries. The table is created in that
+-- shape unless it exists and is brought to the current one below, so
+-- both cases end with the same schema.
+create table if not exists documents (
+	id          bigserial   primary key,
+	author_id   bigint      not null references users (id),
+	uploader_id bigint      not null references users (id),
+	title       text        not null,
+	size        bigint      not null,
+	path        text        not null,
+	hash        text        not null,
+	created_at  timestamptz not null default now(),
+	changed_at  timestamptz not null default now()
+);
+
+alter table documents
+	alter column created_at set default now(),
+	alter column changed_at set default now(),
+	-- MIME type detected on upload
+	add column if not exists content_type text not null default 'application/octet-stream',
+	-- Set when document is moved to trash
+	add column if not exists deleted_at timestamptz;
+
+-- Content hash was unique before documents could share blobs
+alter table documents drop constraint if exists documents_hash_key;
+
+-- Every existing document holds one reference on its content
+insert into blobs (hash, size, ref_count)
+	select hash, max(size), count(*) from documents group by hash;
+
+alter table documents add constraint documents_hash_fkey
+	foreign key (hash) references blobs (hash);
+
+-- Files of existing documents are kept under their path and title,
+-- they are moved into blob store on startup and forgotten then
+create table legacy_files (
+	path  text not null,
+	title text not null,
+	hash  text not null,
+	primary key (path, title)
+);
+
+insert into legacy_files (path, title, hash)
+	select path, title, hash from documents
+		on conflict do nothing;
+
+-- Path and title identify document until it is deleted
+create unique index if not exists documents_location_idx
+	on documents (path, title) where deleted_at is null;
+
+create index if not exists documents_hash_idx
+	on documents (hash);
+
+create index if not exists documents_deleted_at_idx
+	on documents (deleted_at) where deleted_at is not null;
+
+create table if not exists document_versions (
+	id          bigserial   primary key,
+	document_id bigint      not null references documents (id) on delete cascade,
+	version     integer     not null,
+	size        bigint      not null,
+	hash        text        not null references blobs (hash),
+	content_type text       not null default 'application/octet-stream',
+	-- Time when content was replaced by newer one
+	created_at  timestamptz not null default now(),
+	unique (document_id, version)
+);
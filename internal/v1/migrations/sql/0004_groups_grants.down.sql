@@ -0,0 +1,3 @@
+drop table if exists grants;
+drop table if exists group_members;
+drop table if exists groups;
@@ -0,0 +1,39 @@
+-- Named sets of users, grants to group apply to its members
+create table if not exists groups (
+	id         bigserial   primary key,
+	name       text        not null unique,
+	created_at timestamptz not null default now()
+);
+
+create table if not exists group_members (
+	group_id bigint not null references groups (id) on delete cascade,
+	user_id  bigint not null references users (id) on delete cascade,
+	primary key (group_id, user_id)
+);
+
+create index if not exists group_members_user_idx on group_members (user_id);
+
+-- Permission of user or group on document or on folder,
+-- folder grant covers every document under its path
+create table if not exists grants (
+	id          bigserial   primary key,
+	user_id     bigint      references users (id) on delete cascade,
+	group_id    bigint      references groups (id) on delete cascade,
+	document_id bigint      references documents (id) on delete cascade,
+	-- Folder path, '.' is the root
+	path        text,
+	permission  text        not null check (permission in ('read', 'write')),
+	created_at  timestamptz not null default now(),
+	check ((user_id is null) <> (group_id is null)),
+	check ((document_id is null) <> (path is null))
+);
+
+-- One grant per grantee and target
+create unique index if not exists grants_target_idx on grants (
+	coalesce(user_id, 0), coalesce(group_id, 0),
+	coalesce(document_id, 0), coalesce(path, '')
+);
+
+create index if not exists grants_user_idx on grants (user_id);
+create index if not exists grants_group_idx on grants (group_id);
+create index if not exists grants_document_idx on grants (document_id);
@@ -0,0 +1,3 @@
+drop table if exists presign_nonces;
+drop table if exists share_downloads;
+drop table if exists shares;
@@ -0,0 +1,39 @@
+-- Public links to documents, token itself is stored only as hash
+create table if not exists shares (
+	id             bigserial   primary key,
+	document_id    bigint      not null references documents (id) on delete cascade,
+	created_by     bigint      not null references users (id) on delete cascade,
+	-- Leading characters of token to recognize it
+	prefix         text        not null,
+	-- SHA-256 of token
+	token_hash     text        not null unique,
+	-- Never expires if null
+	expires_at     timestamptz,
+	-- Not limited if null
+	max_downloads  integer     check (max_downloads > 0),
+	download_count integer     not null default 0,
+	-- PBKDF2 hash, link is not protected if null
+	password_hash  text,
+	created_at     timestamptz not null default now(),
+	revoked_at     timestamptz
+);
+
+create index if not exists shares_document_idx on shares (document_id);
+
+-- Every download made through share link
+create table if not exists share_downloads (
+	id            bigserial   primary key,
+	share_id      bigint      not null references shares (id) on delete cascade,
+	remote_addr   text        not null,
+	user_agent    text        not null,
+	downloaded_at timestamptz not null default now()
+);
+
+create index if not exists share_downloads_share_idx on share_downloads (share_id);
+
+-- Used nonces of pre-signed upload URLs, each URL works once
+create table if not exists presign_nonces (
+	nonce      text        primary key,
+	-- Nonce can be forgotten after URL expires
+	expires_at timestamptz not null
+);
@@ -0,0 +1,6 @@
+drop index if exists documents_unindexed_idx;
+drop index if exists documents_search_idx;
+
+alter table documents drop column if exists search_vector;
+alter table documents drop column if exists text_hash;
+alter table documents drop column if exists content_text;
@@ -0,0 +1,19 @@
+-- Text extracted from content with hash text_hash, content
+-- with other hash than document has is not indexed yet
+alter table documents add column if not exists content_text text not null default '';
+alter table documents add column if not exists text_hash text;
+
+-- Title weighs more than path, path more than content
+alter table documents add column if not exists search_vector tsvector
+	generated always as (
+		setweight(to_tsvector('english', title), 'A') ||
+		setweight(to_tsvector('english', replace(path, '/', ' ')), 'B') ||
+		setweight(to_tsvector('english', content_text), 'C')
+	) stored;
+
+create index if not exists documents_search_idx
+	on documents using gin (search_vector);
+
+-- Documents waiting for text extraction
+create index if not exists documents_unindexed_idx
+	on documents (id) where text_hash is distinct from hash;
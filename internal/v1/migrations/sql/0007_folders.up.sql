@@ -0,0 +1,15 @@
+-- Folders documents are kept in, documents refer to them by path
+create table if not exists folders (
+	id         bigserial   primary key,
+	-- Null for folders in the root
+	parent_id  bigint      references folders (id),
+	name       text        not null,
+	-- Names of folder and its parents joined by '/'
+	path       text        not null unique,
+	-- Null for folders created for paths of documents
+	owner_id   bigint      references users (id),
+	created_at timestamptz not null default now(),
+	changed_at timestamptz not null default now()
+);
+
+create index if not exists folders_parent_idx on folders (parent_id);
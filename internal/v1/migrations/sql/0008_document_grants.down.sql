@@ -0,0 +1 @@
+drop function if exists document_grants(bigint, bigint, text);
@@ -0,0 +1,12 @@
+-- Permissions granted to user on document with id and path, directly
+-- or through groups, on the document itself or on a folder containing it
+create function document_grants(for_user bigint, doc_id bigint, doc_path text)
+	returns table (permission text)
+	language sql stable
+	as $$
+		select g.permission from grants g
+			where (g.user_id = for_user or g.group_id in
+					(select m.group_id from group_members m where m.user_id = for_user))
+				and (g.document_id = doc_id or g.path = '.' or g.path = doc_path
+					or starts_with(doc_path, g.path || '/'))
+	$$;
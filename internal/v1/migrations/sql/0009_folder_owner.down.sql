@@ -0,0 +1,4 @@
+alter table folders
+	drop constraint folders_owner_id_fkey,
+	add constraint folders_owner_id_fkey
+		foreign key (owner_id) references users (id);
@@ -0,0 +1,6 @@
+-- Folders of deleted user stay without owner, like
+-- folders created for paths of documents
+alter table folders
+	drop constraint folders_owner_id_fkey,
+	add constraint folders_owner_id_fkey
+		foreign key (owner_id) references users (id) on delete set null;
@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"context"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/docs/repository"
+	"docshell/internal/v1/utils"
+	"log"
+	"net/http"
+)
+
+// AuthorizeDocument checks that authenticated user has permission on
+// document, otherwise sends error response and returns false.
+// Document user can not see is reported as not found.
+func AuthorizeDocument(ctx context.Context, w http.ResponseWriter, docs repository.DocumentRepository, id int, permission string) bool {
+	user, _ := auth.UserFromContext(ctx)
+	if Can(user, AccessAllDocuments) {
+		return true
+	}
+
+	granted, err := docs.GetDocumentPermission(ctx, id, user.Id)
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return false
+	}
+
+	switch granted {
+	case aclModels.PermissionWrite, permission:
+		return true
+	case aclModels.PermissionRead:
+		msg := "Document is shared with you read-only"
+		utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+		return false
+	default:
+		msg := "Requested document not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return false
+	}
+}
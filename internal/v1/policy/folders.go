@@ -0,0 +1,43 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	aclModels "docshell/internal/v1/acl/models"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/folders/repository"
+	"docshell/internal/v1/utils"
+	"log"
+	"net/http"
+)
+
+// AuthorizeFolder checks that authenticated user has permission on
+// folder with path, otherwise sends error response and returns false.
+// Anyone whose role allows the action may use the root.
+func AuthorizeFolder(ctx context.Context, w http.ResponseWriter, con *sql.DB, path string, permission string) bool {
+	user, _ := auth.UserFromContext(ctx)
+	if path == "." || Can(user, AccessAllDocuments) {
+		return true
+	}
+
+	granted, err := repository.GetFolderPermission(ctx, con, path, user.Id)
+	if err != nil {
+		log.Println(err)
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return false
+	}
+
+	switch granted {
+	case aclModels.PermissionWrite, permission:
+		return true
+	case aclModels.PermissionRead:
+		msg := "Folder is shared with you read-only"
+		utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+		return false
+	default:
+		msg := "Folder is not shared with you"
+		utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+		return false
+	}
+}
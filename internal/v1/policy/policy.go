@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"context"
+	"docshell/internal/v1/auth"
+	"docshell/internal/v1/users/models"
+	"docshell/internal/v1/utils"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Operation which may be allowed to a role,
+// value describes it in error messages
+type Action string
+
+const (
+	ListDocuments    Action = "list documents"
+	ReadDocument     Action = "read documents"
+	DownloadDocument Action = "download documents"
+	CreateDocument   Action = "create documents"
+	UpdateDocument   Action = "update documents"
+	// Also covers trash and restoring from it
+	DeleteDocument Action = "delete documents"
+	// Bypasses per-document grants
+	AccessAllDocuments Action = "access all documents"
+	// Grants on folders and groups of users
+	ShareFolders Action = "share folders"
+	ManageUsers  Action = "manage users"
+)
+
+// Actions allowed to each role
+var permissions = map[string][]Action{
+	models.RoleViewer: {
+		ListDocuments, ReadDocument, DownloadDocument,
+	},
+	models.RoleEditor: {
+		ListDocuments, ReadDocument, DownloadDocument,
+		CreateDocument, UpdateDocument, DeleteDocument,
+	},
+	models.RoleAdmin: {
+		ListDocuments, ReadDocument, DownloadDocument,
+		CreateDocument, UpdateDocument, DeleteDocument,
+		AccessAllDocuments, ShareFolders, ManageUsers,
+	},
+}
+
+// Can reports whether user's role allows action
+func Can(user models.User, action Action) bool {
+	return slices.Contains(permissions[user.Role], action)
+}
+
+// Authorize checks whether authenticated user may perform action,
+// otherwise sends 403 response and returns false
+func Authorize(ctx context.Context, w http.ResponseWriter, action Action) bool {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok || !Can(user, action) {
+		sendForbidden(w, user, action)
+		return false
+	}
+	return true
+}
+
+// AuthorizeSelf is like Authorize, but user may always
+// act on their own account
+func AuthorizeSelf(ctx context.Context, w http.ResponseWriter, userId int64, action Action) bool {
+	user, ok := auth.UserFromContext(ctx)
+	if ok && user.Id == userId {
+		return true
+	}
+	return Authorize(ctx, w, action)
+}
+
+// Sends consistent response to denied request
+func sendForbidden(w http.ResponseWriter, user models.User, action Action) {
+	msg := fmt.Sprintf("Role '%v' is not allowed to %v", user.Role, action)
+	utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+}
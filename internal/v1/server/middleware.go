@@ -34,7 +34,8 @@ func LoggingMiddleware(next Handler) Handler {
 	}
 }
 
-// AuthMiddleware is a simple authentication middleware
+// AuthMiddleware is a simple authentication middleware.
+// Deprecated: it does not verify the token, use authn.AuthMiddleware.
 func AuthMiddleware(next Handler) Handler {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Check for auth token (this is a very simplistic example)
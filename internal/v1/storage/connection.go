@@ -5,24 +5,23 @@ import (
 	docshell "docshell/internal/v1/config"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"strconv"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
-var db *sql.DB
-
-func init() {
-	// Expose config to local variable
-	cfg := docshell.Config
+// Open opens Postgres database described in cfg and checks connection
+func Open(cfg docshell.Configuration) (*sql.DB, error) {
 	// Build connection string
 	con := buildConnectionString(cfg)
 
 	// Open database
-	var err error
-	db, err = sql.Open("postgres", con)
+	db, err := sql.Open("postgres", con)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// Setting up database setting
@@ -32,35 +31,26 @@ func init() {
 	db.SetMaxIdleConns(cfg.Service.DB.Settings.MaxIdleConns)
 
 	// Check connection
-	if ok, err := CheckConnecton(); !ok {
-		msg := "Connection to \"%s\" fault with %v"
-		log.Fatalf(msg, con, err)
-	}
-	log.Printf("Connection to \"%s\" established.\n", con)
-}
-
-func GetConnection() *sql.DB {
-	return db
-}
-
-func CheckConnecton() (bool, error) {
+	c := cfg.Service.DB
 	if err := db.Ping(); err != nil {
-		return false, err
+		db.Close()
+		return nil, fmt.Errorf("connection to %s:%d fault with %w", c.Host, c.Port, err)
 	}
-	return true, nil
+	log.Printf("Connection to %s:%d established.\n", c.Host, c.Port)
+	return db, nil
 }
 
+// Builds URL of database, credentials are escaped
+// since generated passwords often contain '@', '/' or '#'
 func buildConnectionString(cfg docshell.Configuration) string {
-	format := "postgres://%s:%s@%s:%d/%s?sslmode=%s"
 	c := cfg.Service.DB
 
-	return fmt.Sprintf(
-		format,
-		c.Environment.PostgresUser,
-		c.Environment.PostgresPassword,
-		c.Host,
-		c.Port,
-		c.Environment.PostgresDB,
-		c.SSLMode,
-	)
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.Environment.PostgresUser, c.Environment.PostgresPassword),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Environment.PostgresDB,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+	return u.String()
 }
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	docshell "docshell/internal/v1/config"
+	"net/url"
+	"testing"
+)
+
+func TestBuildConnectionString(t *testing.T) {
+	var cfg docshell.Configuration
+	cfg.Service.DB.Host = "::1"
+	cfg.Service.DB.Port = 5432
+	cfg.Service.DB.SSLMode = "disable"
+	cfg.Service.DB.Environment.PostgresUser = "doc user"
+	cfg.Service.DB.Environment.PostgresPassword = "p@ss:w/rd?#%"
+	cfg.Service.DB.Environment.PostgresDB = "docshell"
+
+	con := buildConnectionString(cfg)
+	want := "postgres://doc%20user:p%40ss%3Aw%2Frd%3F%23%25@[::1]:5432/docshell?sslmode=disable"
+	if con != want {
+		t.Fatalf("buildConnectionString() = %s, want %s", con, want)
+	}
+
+	// Credentials are read back unchanged
+	u, err := url.Parse(con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, _ := u.User.Password()
+	if u.User.Username() != "doc user" || password != "p@ss:w/rd?#%" {
+		t.Fatalf("credentials = %q, %q", u.User.Username(), password)
+	}
+}
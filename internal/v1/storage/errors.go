@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// Postgres error code for unique constraint violation
+	uniqueViolation = "23505"
+	// Postgres error code for foreign key constraint violation
+	foreignKeyViolation = "23503"
+)
+
+// Constraint errors of stores other than Postgres
+var (
+	ErrUniqueViolation     = errors.New("unique constraint violated")
+	ErrForeignKeyViolation = errors.New("foreign key constraint violated")
+)
+
+// IsUniqueViolation reports whether err is caused by unique constraint
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == uniqueViolation
+	}
+	return errors.Is(err, ErrUniqueViolation)
+}
+
+// IsForeignKeyViolation reports whether err is caused by foreign key constraint
+func IsForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == foreignKeyViolation
+	}
+	return errors.Is(err, ErrForeignKeyViolation)
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// Executor runs queries, it is satisfied by both *sql.DB and *sql.Tx,
+// so repository functions taking it may run inside transaction
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// UnitOfWork groups database changes made in one transaction. Work done
+// outside database, e.g. in the volume, is left to caller after Commit,
+// so it is never done for changes which were rolled back.
+type UnitOfWork struct {
+	Tx *sql.Tx
+
+	done bool
+}
+
+// Begin starts unit of work, it must be ended
+// with Commit or Rollback
+func Begin(ctx context.Context, con *sql.DB) (*UnitOfWork, error) {
+	tx, err := con.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &UnitOfWork{Tx: tx}, nil
+}
+
+// Commit commits transaction
+func (u *UnitOfWork) Commit() error {
+	u.done = true
+	return u.Tx.Commit()
+}
+
+// Rollback rolls transaction back, it does nothing
+// after Commit, so it can be deferred
+func (u *UnitOfWork) Rollback() {
+	if u.done {
+		return
+	}
+	u.done = true
+	if err := u.Tx.Rollback(); err != nil {
+		log.Println(err)
+	}
+}
@@ -1 +1,148 @@
 package handlers
+
+import (
+	"docshell/internal/v1/policy"
+	"docshell/internal/v1/users/models"
+	"docshell/internal/v1/users/service"
+	"docshell/internal/v1/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Maximum size of request body
+const maxBodySize = 1 << 20
+
+// Handler serves user accounts
+type Handler struct {
+	service *service.Service
+}
+
+func New(service *service.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ManageUsers) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetAllUsers(ctx, w, r)
+}
+
+func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Users may read their own account
+	if !policy.AuthorizeSelf(ctx, w, id, policy.ManageUsers) {
+		return
+	}
+	// Call next function and pass context
+	h.service.GetUserById(ctx, w, r, id)
+}
+
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	// Check role of user
+	if !policy.Authorize(r.Context(), w, policy.ManageUsers) {
+		return
+	}
+	// Struct to put in it parsed body
+	var uc models.UserCreation
+	if !readBody(w, r, &uc) {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Call next function and pass context
+	h.service.CreateUser(ctx, w, r, uc)
+}
+
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r)
+	if !ok {
+		return
+	}
+	// Struct to put in it parsed body
+	var uu models.UserUpdate
+	if !readBody(w, r, &uu) {
+		return
+	}
+	// PUT replaces all fields except password, PATCH changes only sent fields
+	if r.Method == http.MethodPut && (uu.Name == nil || uu.Email == nil) {
+		msg := "Fields 'name' and 'email' are required"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Users may change their own account, but not their role
+	if !policy.AuthorizeSelf(ctx, w, id, policy.ManageUsers) {
+		return
+	}
+	if uu.Role != nil && !policy.Authorize(ctx, w, policy.ManageUsers) {
+		return
+	}
+	// Call next function and pass context
+	h.service.UpdateUser(ctx, w, r, id, uu)
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	// Read path value
+	id, ok := readId(w, r)
+	if !ok {
+		return
+	}
+	// Set context for chain
+	ctx := r.Context()
+	// Check role of user
+	if !policy.Authorize(ctx, w, policy.ManageUsers) {
+		return
+	}
+	// Call next function and pass context
+	h.service.DeleteUser(ctx, w, r, id)
+}
+
+// Reads 'id' path value, sends error response if incorrect
+func readId(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || 0 >= id {
+		msg := fmt.Sprintf("Path value 'id=%v' incorrect", r.PathValue("id"))
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return 0, false
+	}
+	return id, true
+}
+
+// Decodes JSON body into v, sends error response if incorrect
+func readBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	// Read body
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		msg := "Body can not be read"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	if len(body) == 0 { // if empty
+		msg := "Body is empty"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	// Try to decode body into the struct
+	if err := json.Unmarshal(body, v); err != nil {
+		msg := "JSON is incorrect"
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return false
+	}
+	return true
+}
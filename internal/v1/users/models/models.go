@@ -0,0 +1,52 @@
+package models
+
+// Roles of users, each one includes permissions of previous
+const (
+	// Reads documents
+	RoleViewer = "viewer"
+	// Also creates, changes and deletes documents
+	RoleEditor = "editor"
+	// Also manages users
+	RoleAdmin = "admin"
+)
+
+// Roles lists all roles
+var Roles = []string{RoleViewer, RoleEditor, RoleAdmin}
+
+type User struct {
+	Id           int64  `json:"id" db:"id"`
+	Name         string `json:"name" db:"name"`
+	Email        string `json:"email" db:"email"`
+	PasswordHash string `json:"-" db:"password_hash"`
+	Role         string `json:"role" db:"role"`
+	CreatedAt    string `json:"created_at" db:"created_at"`
+	ChangedAt    string `json:"changed_at" db:"changed_at"`
+}
+
+type UserCreation struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	// Viewer if empty
+	Role string `json:"role"`
+}
+
+// Fields left nil are not changed
+type UserUpdate struct {
+	Name     *string `json:"name"`
+	Email    *string `json:"email"`
+	Password *string `json:"password"`
+	// Required when users change their own password
+	CurrentPassword *string `json:"current_password"`
+	Role            *string `json:"role"`
+}
+
+type ResponseMultipleUsers struct {
+	StatusCode int    `json:"status_code"`
+	Users      []User `json:"users"`
+}
+
+type ResponseSingleUser struct {
+	StatusCode int  `json:"status_code"`
+	User       User `json:"user"`
+}
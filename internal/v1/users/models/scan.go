@@ -0,0 +1,12 @@
+package models
+
+import "database/sql"
+
+func ScanUser(rows *sql.Rows) (User, error) {
+	user := User{}
+	if err := rows.Scan(&user.Id, &user.Name, &user.Email,
+		&user.PasswordHash, &user.Role, &user.CreatedAt, &user.ChangedAt); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
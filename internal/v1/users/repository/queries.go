@@ -0,0 +1,25 @@
+package repository
+
+const (
+	user_columns = "id, name, email, password_hash, role, created_at, changed_at"
+
+	get_all_users     = "select " + user_columns + " from users order by id;"
+	get_user_by_id    = "select " + user_columns + " from users where id = $1;"
+	get_users_by_id   = "select " + user_columns + " from users where id = any($1);"
+	get_user_by_email = "select " + user_columns + " from users where email = $1;"
+	insert_user       = `
+		insert into users (name, email, password_hash, role)
+			values ($1, $2, $3, $4)
+			returning ` + user_columns + `;
+	`
+	update_user = `
+		update users
+			set name = $2, email = $3, password_hash = $4, role = $5, changed_at = now()
+			where id = $1
+			returning ` + user_columns + `;
+	`
+	delete_user = "delete from users where id = $1 returning " + user_columns + ";"
+	// Admins are locked, so concurrent demotions can not remove the last one
+	lock_admins = "select id from users where role = 'admin' order by id for update;"
+	count_users = "select count(*) from users;"
+)
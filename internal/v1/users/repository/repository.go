@@ -1 +1,117 @@
-package repository
\ No newline at end of file
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/storage"
+	"docshell/internal/v1/users/models"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var ErrLastAdmin = errors.New("last admin can not be demoted or deleted")
+
+func GetAllUsers(ctx context.Context, con *sql.DB) ([]models.User, error) {
+	return queryUsers(ctx, con, get_all_users)
+}
+
+func GetUserById(ctx context.Context, con *sql.DB, id int64) (models.User, error) {
+	return queryUser(ctx, con, get_user_by_id, id)
+}
+
+// GetUsersByIds returns users with given ids, missing ones are skipped
+func GetUsersByIds(ctx context.Context, con *sql.DB, ids []int64) ([]models.User, error) {
+	return queryUsers(ctx, con, get_users_by_id, pq.Array(ids))
+}
+
+func GetUserByEmail(ctx context.Context, con *sql.DB, email string) (models.User, error) {
+	return queryUser(ctx, con, get_user_by_email, email)
+}
+
+func CreateUser(ctx context.Context, con *sql.DB, name, email, passwordHash, role string) (models.User, error) {
+	return queryUser(ctx, con, insert_user, name, email, passwordHash, role)
+}
+
+// UpdateUser saves user and returns it, ErrLastAdmin is
+// returned if it is the last admin losing its role
+func UpdateUser(ctx context.Context, con *sql.DB, user models.User) (models.User, error) {
+	return changeUser(ctx, con, user.Id, user.Role != models.RoleAdmin,
+		update_user, user.Id, user.Name, user.Email, user.PasswordHash, user.Role)
+}
+
+// DeleteUser removes user and returns it,
+// ErrLastAdmin is returned if it is the last admin
+func DeleteUser(ctx context.Context, con *sql.DB, id int64) (models.User, error) {
+	return changeUser(ctx, con, id, true, delete_user, id)
+}
+
+// CountUsers returns number of registered users
+func CountUsers(ctx context.Context, con *sql.DB) (int64, error) {
+	var count int64
+	err := con.QueryRowContext(ctx, count_users).Scan(&count)
+	return count, err
+}
+
+// Runs query changing user with id in transaction, if user stops
+// being admin there must be another admin left
+func changeUser(ctx context.Context, con *sql.DB, id int64, leavesAdmins bool,
+	query string, args ...any) (models.User, error) {
+	tx, err := con.BeginTx(ctx, nil)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer tx.Rollback()
+
+	if leavesAdmins {
+		rows, err := tx.QueryContext(ctx, lock_admins)
+		if err != nil {
+			return models.User{}, err
+		}
+		admins, err := storage.ScanMany(rows, func(rows *sql.Rows) (int64, error) {
+			var id int64
+			err := rows.Scan(&id)
+			return id, err
+		})
+		rows.Close()
+		if err != nil {
+			return models.User{}, err
+		}
+		if len(admins) == 1 && admins[0] == id {
+			return models.User{}, ErrLastAdmin
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.User{}, err
+	}
+	user, err := storage.ScanSingle(rows, models.ScanUser)
+	rows.Close()
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, tx.Commit()
+}
+
+// Runs query returning single user
+func queryUser(ctx context.Context, con *sql.DB, query string, args ...any) (models.User, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer rows.Close()
+
+	return storage.ScanSingle(rows, models.ScanUser)
+}
+
+// Runs query returning many users
+func queryUsers(ctx context.Context, con *sql.DB, query string, args ...any) ([]models.User, error) {
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return storage.ScanMany(rows, models.ScanUser)
+}
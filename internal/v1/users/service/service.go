@@ -1 +1,322 @@
-package service
\ No newline at end of file
+package service
+
+import (
+	"context"
+	"database/sql"
+	"docshell/internal/v1/auth"
+	authRepository "docshell/internal/v1/auth/repository"
+	"docshell/internal/v1/storage"
+	util "docshell/internal/v1/users"
+	"docshell/internal/v1/users/models"
+	"docshell/internal/v1/users/repository"
+	"docshell/internal/v1/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Service manages user accounts
+type Service struct {
+	// Database of users
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) GetAllUsers(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Get all users from repository
+	users, err := repository.GetAllUsers(ctx, con)
+	if err != nil {
+		msg := "Database error: could not read users"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Build response
+	res := models.ResponseMultipleUsers{
+		StatusCode: http.StatusOK,
+		Users:      make([]models.User, len(users)),
+	}
+	copy(res.Users, users)
+	utils.SendJSONResponse(w, res)
+}
+
+func (s *Service) GetUserById(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Get user
+	user, err := repository.GetUserById(ctx, con, id)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if user == (models.User{}) {
+		msg := "Requested user not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleUser{
+		StatusCode: http.StatusOK,
+		User:       user,
+	})
+}
+
+func (s *Service) CreateUser(ctx context.Context, w http.ResponseWriter, r *http.Request, uc models.UserCreation) {
+	// Validate fields
+	uc.Name = strings.TrimSpace(uc.Name)
+	uc.Email = normalizeEmail(uc.Email)
+	if uc.Role == "" {
+		uc.Role = models.RoleViewer
+	}
+	if msg, ok := validateUser(uc.Name, uc.Email, uc.Role, &uc.Password); !ok {
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Hash password
+	hash, err := util.HashPassword(uc.Password)
+	if err != nil {
+		log.Println(err)
+		msg := "Password hashing fault"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Save user
+	user, err := repository.CreateUser(ctx, con, uc.Name, uc.Email, hash, uc.Role)
+	if err != nil {
+		sendUserError(w, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleUser{
+		StatusCode: http.StatusOK,
+		User:       user,
+	})
+}
+
+func (s *Service) UpdateUser(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64, uu models.UserUpdate) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Get current user
+	user, err := repository.GetUserById(ctx, con, id)
+	if err != nil {
+		msg := "Internal server error"
+		utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+		return
+	}
+	if user == (models.User{}) {
+		msg := "Requested user not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	// Apply changed fields over current ones
+	if uu.Name != nil {
+		user.Name = strings.TrimSpace(*uu.Name)
+	}
+	if uu.Email != nil {
+		user.Email = normalizeEmail(*uu.Email)
+	}
+	if uu.Role != nil {
+		user.Role = *uu.Role
+	}
+	if msg, ok := validateUser(user.Name, user.Email, user.Role, uu.Password); !ok {
+		utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Users changing their own password must know the current one,
+	// so stolen access token is not enough to take over account
+	current, _ := auth.UserFromContext(ctx)
+	if uu.Password != nil && current.Id == user.Id {
+		if uu.CurrentPassword == nil {
+			msg := "Field 'current_password' is required to change own password"
+			utils.SendJSONErrorResponse(w, http.StatusBadRequest, msg)
+			return
+		}
+		if !util.CheckPassword(*uu.CurrentPassword, user.PasswordHash) {
+			msg := "Field 'current_password' incorrect"
+			utils.SendJSONErrorResponse(w, http.StatusForbidden, msg)
+			return
+		}
+	}
+
+	// Hash new password
+	if uu.Password != nil {
+		user.PasswordHash, err = util.HashPassword(*uu.Password)
+		if err != nil {
+			log.Println(err)
+			msg := "Password hashing fault"
+			utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+			return
+		}
+	}
+
+	// Save user
+	user, err = repository.UpdateUser(ctx, con, user)
+	if err != nil {
+		sendUserError(w, err)
+		return
+	}
+	if user == (models.User{}) {
+		msg := "Requested user not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	// Log out everywhere after password change
+	if uu.Password != nil {
+		if err := authRepository.RevokeUserSessions(ctx, con, user.Id); err != nil {
+			log.Println(err)
+		}
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleUser{
+		StatusCode: http.StatusOK,
+		User:       user,
+	})
+}
+
+func (s *Service) DeleteUser(ctx context.Context, w http.ResponseWriter, r *http.Request, id int64) {
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Delete user
+	user, err := repository.DeleteUser(ctx, con, id)
+	if err != nil {
+		sendUserError(w, err)
+		return
+	}
+	if user == (models.User{}) {
+		msg := "Requested user not found"
+		utils.SendJSONErrorResponse(w, http.StatusNotFound, msg)
+		return
+	}
+
+	utils.SendJSONResponse(w, models.ResponseSingleUser{
+		StatusCode: http.StatusOK,
+		User:       user,
+	})
+}
+
+// EnsureAdmin creates admin with given name, email and password when
+// there are no users yet, so the first one can sign in and create others.
+// Nothing is created without password.
+func (s *Service) EnsureAdmin(ctx context.Context, name, email, password string) error {
+	if password == "" {
+		return nil
+	}
+
+	// Set timeout context
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Get db connection
+	con := s.db
+
+	// Only empty database is seeded
+	count, err := repository.CountUsers(ctx, con)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	name, email = strings.TrimSpace(name), normalizeEmail(email)
+	if msg, ok := validateUser(name, email, models.RoleAdmin, &password); !ok {
+		return errors.New(msg)
+	}
+	hash, err := util.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if _, err := repository.CreateUser(ctx, con, name, email, hash, models.RoleAdmin); err != nil {
+		return err
+	}
+	log.Printf("Created admin %v", email)
+	return nil
+}
+
+// Checks user fields, returns message describing first incorrect one.
+// Password is checked only if it is set.
+func validateUser(name, email, role string, password *string) (string, bool) {
+	if name == "" {
+		return "Name is empty", false
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return fmt.Sprintf("Email '%v' incorrect", email), false
+	}
+	if !slices.Contains(models.Roles, role) {
+		return fmt.Sprintf("Role '%v' incorrect, expected one of %v", role, models.Roles), false
+	}
+	if password != nil && len(*password) < util.MinPasswordLength {
+		return fmt.Sprintf("Password must be at least %d characters", util.MinPasswordLength), false
+	}
+	return "", true
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Sends response matching repository error
+func sendUserError(w http.ResponseWriter, err error) {
+	// Someone must be able to manage users
+	if errors.Is(err, repository.ErrLastAdmin) {
+		msg := "Last admin can not be demoted or deleted"
+		utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+		return
+	}
+	// Email is unique
+	if storage.IsUniqueViolation(err) {
+		msg := "User with such email already exists"
+		utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+		return
+	}
+	// Documents keep their author and uploader, other
+	// records of user are removed or lose their owner
+	if storage.IsForeignKeyViolation(err) {
+		msg := "User is author or uploader of documents, in trash too"
+		utils.SendJSONErrorResponse(w, http.StatusConflict, msg)
+		return
+	}
+	log.Println(err)
+	msg := "Database error: could not save user"
+	utils.SendJSONErrorResponse(w, http.StatusInternalServerError, msg)
+}
@@ -7,65 +7,170 @@ import (
 	"docshell/internal/v1/volume"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"mime/multipart"
+	"mime"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
+	"strings"
+	"unicode/utf8"
 )
 
-func UploadFile(ctx context.Context, path string, file io.Reader, handler *multipart.FileHeader) error {
-	// Create path if not exists
-	volDir := filepath.Join(volume.GetPath(), path)
-	if err := CreateDir(volDir); err != nil {
-		return err
+// Returned when uploaded file exceeds maximum size
+var ErrFileTooLarge = errors.New("file exceeds maximum upload size")
+
+// File written to the storage's staging area
+// but not yet moved to blob store
+type StagedFile struct {
+	Key      string
+	Filename string
+	Size     int64
+	Hash     string
+	// MIME type detected from content and file name
+	ContentType string
+	// Storage file is staged in
+	store volume.Storage
+	// Set when file is moved to blob store
+	committed bool
+}
+
+// StageFile streams file to staging area of store,
+// computes its SHA-512 hash on the fly and enforces maxSize in bytes.
+func StageFile(ctx context.Context, store volume.Storage, file io.Reader, filename string, maxSize int64) (*StagedFile, error) {
+	staged := &StagedFile{
+		Key:      volume.NewStagingKey(),
+		Filename: filename,
+		store:    store,
 	}
 
-	// Create a temporary file in the volume's directory
-	tempFile, err := os.CreateTemp(volDir, "upload_*")
+	// Write file, hash it and keep its head for sniffing at once
+	sha := sha512.New()
+	head := &headWriter{}
+	limited := &limitedReader{r: file, max: maxSize}
+	size, err := store.Put(ctx, staged.Key,
+		io.TeeReader(limited, io.MultiWriter(sha, head)))
 	if err != nil {
-		return err
+		DiscardFile(staged)
+		return nil, err
 	}
-	// Ensure the temporary file is removed if an error occurs
-	defer os.Remove(tempFile.Name())
-	// Close the file when done
-	defer tempFile.Close()
 
-	// Read the uploaded file into a byte array
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
-		return err
+	staged.Size = size
+	staged.Hash = hex.EncodeToString(sha.Sum(nil))
+	staged.ContentType = DetectContentType(head.buf, filename)
+	return staged, nil
+}
+
+// CommitBlob moves staged file into blob store of its storage under its
+// hash. If blob with the same hash is already stored, staged file is discarded.
+func CommitBlob(ctx context.Context, file *StagedFile) error {
+	store := file.store
+	blobKey := volume.GetBlobKey(file.Hash)
+	if _, err := store.Stat(ctx, blobKey); err == nil {
+		DiscardFile(file)
+		file.committed = true
+		return nil
 	}
 
-	// Write the byte array to the temporary file
-	if _, err := tempFile.Write(fileBytes); err != nil {
+	if err := store.Move(ctx, file.Key, blobKey); err != nil {
 		return err
 	}
+	file.committed = true
+	return nil
+}
 
-	// Ensure all data is flushed to disk
-	if err := tempFile.Sync(); err != nil {
-		return err
+// OpenBlob opens blob of store with given hash for reading
+func OpenBlob(ctx context.Context, store volume.Storage, hash string) (io.ReadSeekCloser, error) {
+	return store.Get(ctx, volume.GetBlobKey(hash))
+}
+
+// DiscardFile removes staged file if it was not committed
+func DiscardFile(file *StagedFile) {
+	if file == nil || file.committed {
+		return
 	}
+	file.store.Delete(context.Background(), file.Key)
+}
 
-	// Close the file explicitly before renaming (required for Windows compatibility)
-	if err := tempFile.Close(); err != nil {
-		return err
+// DetectContentType returns MIME type of file by its first bytes
+// and extension. Sniffed type wins unless it is generic or container
+// format, which extension usually describes more precisely.
+func DetectContentType(head []byte, filename string) string {
+	sniffed := http.DetectContentType(head)
+	byExt := mime.TypeByExtension(strings.ToLower(path.Ext(filename)))
+	if byExt == "" {
+		return sniffed
 	}
 
-	// Rename the temporary file to the original filename
-	newPath := filepath.Join(volDir, handler.Filename)
-	if err := os.Rename(tempFile.Name(), newPath); err != nil {
-		return err
+	base, _, _ := mime.ParseMediaType(sniffed)
+	switch base {
+	case "application/octet-stream", "text/plain", "application/zip", "text/xml":
+		return byExt
 	}
+	return sniffed
+}
 
-	// Check if the context was canceled
-	if err := ctx.Err(); err != nil {
-		// Optionally remove the renamed file if the context was canceled
-		os.Remove(newPath)
-		return err
+// Writer keeping first bytes needed for content sniffing
+type headWriter struct {
+	buf []byte
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	// http.DetectContentType reads at most 512 bytes
+	if n := 512 - len(h.buf); n > 0 {
+		h.buf = append(h.buf, p[:min(n, len(p))]...)
 	}
+	return len(p), nil
+}
 
-	return nil
+// Reader failing with ErrFileTooLarge after max bytes
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.max {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+// CleanPath normalizes slash separated document path, so it
+// never points above the root; root itself is returned as "."
+func CleanPath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// ContentDisposition builds Content-Disposition header value (RFC 6266)
+// with ASCII fallback filename and UTF-8 encoded filename* (RFC 5987)
+func ContentDisposition(disposition, filename string) string {
+	var fallback, encoded strings.Builder
+	for i := 0; i < len(filename); i++ {
+		c := filename[i]
+		// Keep printable ASCII except quoting characters
+		if c >= 0x20 && c < 0x7f && c != '"' && c != '\\' {
+			fallback.WriteByte(c)
+		} else if c < 0x80 || utf8.RuneStart(c) {
+			fallback.WriteByte('_')
+		}
+		// attr-char of RFC 5987
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`,
+		disposition, fallback.String(), encoded.String())
 }
 
 func SendJSONResponse(w http.ResponseWriter, res any) {
@@ -74,11 +179,13 @@ func SendJSONResponse(w http.ResponseWriter, res any) {
 }
 
 func SendJSONErrorResponse(w http.ResponseWriter, code int, msg string) {
-	// Send http error
-	http.Error(w, msg, code)
-	// Send json error response
+	// Error body is JSON only, headers are set before status is written
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
 
-	SendJSONResponse(w,
+	// Send json error response
+	json.NewEncoder(w).Encode(
 		models.ErrorResponse{
 			StatusCode: code,
 			StatusText: http.StatusText(code),
@@ -86,17 +193,3 @@ func SendJSONErrorResponse(w http.ResponseWriter, code int, msg string) {
 		},
 	)
 }
-
-func GenerateHash(b []byte) (hash string, err error) {
-	sha := sha512.New()
-	_, err = sha.Write(b)
-	if err != nil {
-		return "", err
-	}
-	sum := sha.Sum(nil)
-	return hex.EncodeToString(sum), nil
-}
-
-func CreateDir(path string) error {
-	return os.MkdirAll(path, 0666)
-}
@@ -0,0 +1,97 @@
+package utils
+
+import "testing"
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name        string
+		disposition string
+		filename    string
+		want        string
+	}{
+		{
+			name:        "ASCII",
+			disposition: "attachment",
+			filename:    "report.pdf",
+			want:        `attachment; filename="report.pdf"; filename*=UTF-8''report.pdf`,
+		},
+		{
+			name:        "UTF-8",
+			disposition: "attachment",
+			filename:    "naïve café.txt",
+			want:        `attachment; filename="na_ve caf_.txt"; filename*=UTF-8''na%C3%AFve%20caf%C3%A9.txt`,
+		},
+		{
+			name:        "four byte character",
+			disposition: "inline",
+			filename:    "😀.png",
+			want:        `inline; filename="_.png"; filename*=UTF-8''%F0%9F%98%80.png`,
+		},
+		{
+			name:        "quotes and backslash",
+			disposition: "attachment",
+			filename:    `say "hi"\x.txt`,
+			want:        `attachment; filename="say _hi__x.txt"; filename*=UTF-8''say%20%22hi%22%5Cx.txt`,
+		},
+		{
+			name:        "line break",
+			disposition: "attachment",
+			filename:    "a\r\nSet-Cookie: x=1; y",
+			want:        `attachment; filename="a__Set-Cookie: x=1; y"; filename*=UTF-8''a%0D%0ASet-Cookie%3A%20x%3D1%3B%20y`,
+		},
+		{
+			name:        "percent and parentheses",
+			disposition: "attachment",
+			filename:    "100% 'done'(1).txt",
+			want:        `attachment; filename="100% 'done'(1).txt"; filename*=UTF-8''100%25%20%27done%27%281%29.txt`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContentDisposition(tt.disposition, tt.filename); got != tt.want {
+				t.Errorf("ContentDisposition() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name     string
+		head     []byte
+		filename string
+		want     string
+	}{
+		{name: "sniffed PDF", head: []byte("%PDF-1.7\n"), filename: "scan.pdf", want: "application/pdf"},
+		{name: "sniffed type wins over extension", head: png, filename: "notes.txt", want: "image/png"},
+		{name: "extension refines plain text", head: []byte(`{"a": 1}`), filename: "data.json", want: "application/json"},
+		{name: "extension refines XML", head: []byte(`<?xml version="1.0"?><svg/>`), filename: "logo.svg",
+			want: "image/svg+xml"},
+		{name: "extension of empty file", head: nil, filename: "site.CSS", want: "text/css; charset=utf-8"},
+		{name: "HTML is not hidden by extension", head: []byte("<html><script>x</script>"), filename: "image.png",
+			want: "text/html; charset=utf-8"},
+		{name: "text without extension", head: []byte("plain words"), filename: "README", want: "text/plain; charset=utf-8"},
+		{name: "binary without extension", head: []byte{0, 1, 2, 3}, filename: "blob", want: "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContentType(tt.head, tt.filename); got != tt.want {
+				t.Errorf("DetectContentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadWriter(t *testing.T) {
+	var h headWriter
+	for range 3 {
+		n, err := h.Write(make([]byte, 200))
+		if n != 200 || err != nil {
+			t.Fatalf("Write() = %d, %v, want 200, nil", n, err)
+		}
+	}
+	if len(h.buf) != 512 {
+		t.Fatalf("kept %d bytes, want 512", len(h.buf))
+	}
+}
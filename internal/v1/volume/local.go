@@ -0,0 +1,130 @@
+package volume
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage keeping files in local directory
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	name := s.path(key)
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	// Write to temporary file first, so readers never see partial file
+	tempFile, err := os.CreateTemp(dir, ".put_*")
+	if err != nil {
+		return 0, err
+	}
+	// Ensure the temporary file is removed if an error occurs
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	n, err := io.Copy(tempFile, r)
+	if err != nil {
+		return n, err
+	}
+	// Ensure all data is flushed to disk
+	if err := tempFile.Sync(); err != nil {
+		return n, err
+	}
+	// Close the file explicitly before renaming (required for Windows compatibility)
+	if err := tempFile.Close(); err != nil {
+		return n, err
+	}
+	// Check if the context was canceled
+	if err := ctx.Err(); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tempFile.Name(), name)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	return os.Open(s.path(key))
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (FileInfo, error) {
+	info, err := os.Stat(s.path(key))
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	return os.Remove(s.path(key))
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	// Walk only directory containing prefix
+	dir := prefix
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(dir)
+	}
+
+	var files []FileInfo
+	err := filepath.WalkDir(s.path(dir), func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		// Skip files not matching prefix and unfinished puts
+		if !strings.HasPrefix(key, prefix) || strings.HasPrefix(d.Name(), ".put_") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, FileInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return files, err
+}
+
+func (s *LocalStorage) Move(ctx context.Context, from, to string) error {
+	name := s.path(to)
+	if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(s.path(from), name)
+}
+
+// Close does nothing, files are kept in root
+func (s *LocalStorage) Close() error {
+	return nil
+}
+
+// Converts key to path inside root, key can not escape root
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+key)))
+}
@@ -0,0 +1,111 @@
+package volume
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage keeping files in memory, content is lost on exit
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: make(map[string]memoryFile)}
+}
+
+func (s *MemoryStorage) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return int64(len(data)), err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = memoryFile{data: data, modTime: time.Now()}
+	return int64(len(data)), nil
+}
+
+func (s *MemoryStorage) Get(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[key]
+	if !ok {
+		return nil, ErrNotExist
+	}
+	// Data is never modified in place, so it can be shared
+	return memoryReader{bytes.NewReader(file.data)}, nil
+}
+
+// Reader of memory file, closing it does nothing
+type memoryReader struct {
+	*bytes.Reader
+}
+
+func (memoryReader) Close() error {
+	return nil
+}
+
+func (s *MemoryStorage) Stat(ctx context.Context, key string) (FileInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[key]
+	if !ok {
+		return FileInfo{}, ErrNotExist
+	}
+	return FileInfo{Key: key, Size: int64(len(file.data)), ModTime: file.modTime}, nil
+}
+
+func (s *MemoryStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[key]; !ok {
+		return ErrNotExist
+	}
+	delete(s.files, key)
+	return nil
+}
+
+func (s *MemoryStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var files []FileInfo
+	for key, file := range s.files {
+		if strings.HasPrefix(key, prefix) {
+			files = append(files, FileInfo{Key: key, Size: int64(len(file.data)), ModTime: file.modTime})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Key < files[j].Key })
+	return files, nil
+}
+
+func (s *MemoryStorage) Move(ctx context.Context, from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[from]
+	if !ok {
+		return ErrNotExist
+	}
+	s.files[to] = file
+	delete(s.files, from)
+	return nil
+}
+
+// Close drops all files
+func (s *MemoryStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.files)
+	return nil
+}
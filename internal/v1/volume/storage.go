@@ -0,0 +1,39 @@
+package volume
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// Returned when file with given key does not exist,
+// it matches os.ErrNotExist with errors.Is
+var ErrNotExist = fs.ErrNotExist
+
+// Storage keeps files addressed by slash separated keys
+type Storage interface {
+	// Put writes content of r under key, replacing existing file,
+	// and returns number of written bytes
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Get opens file for reading, reader can seek
+	// to serve ranges of the file
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Stat returns information about file
+	Stat(ctx context.Context, key string) (FileInfo, error)
+	// Delete removes file
+	Delete(ctx context.Context, key string) error
+	// List returns files whose keys start with prefix
+	List(ctx context.Context, prefix string) ([]FileInfo, error)
+	// Move renames file, replacing existing one
+	Move(ctx context.Context, from, to string) error
+	// Close releases resources held by storage
+	Close() error
+}
+
+// Information about stored file
+type FileInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
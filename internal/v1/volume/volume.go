@@ -1,32 +1,61 @@
 package volume
 
 import (
+	"context"
+	"crypto/rand"
 	doconf "docshell/internal/v1/config"
+	"encoding/hex"
+	"fmt"
 	"log"
-	"os"
 )
 
-// Volume path
-var path string
+const (
+	// Key prefix for not yet committed uploads
+	stagingPrefix = "staging/"
+	// Key prefix for content addressed blobs
+	blobsPrefix = "blobs/"
+)
 
-// Set up
-func init() {
-	cfg := doconf.Config
-	path = setupVolume(cfg.Volume)
-	log.Printf("Choosed volume path %v", path)
-}
+// Open sets up storage backend chosen in config for saving documents
+func Open(cfg doconf.Configuration) (Storage, error) {
+	var s Storage
+	var err error
+
+	switch backend := cfg.Storage.Backend; backend {
+	case "", "local":
+		s, err = NewLocalStorage(cfg.Volume)
+		log.Printf("Choosed volume path %v", cfg.Volume)
+	case "memory":
+		s = NewMemoryStorage()
+		log.Println("Choosed in-memory storage, documents are lost on exit")
+	case "s3":
+		c := cfg.Storage.S3
+		s, err = NewS3Storage(c.Endpoint, c.Region, c.Bucket, c.AccessKey, c.SecretKey)
+		log.Printf("Choosed s3 bucket %v at %v", c.Bucket, c.Endpoint)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %v", backend)
+	}
 
-// Setup volume for saving documents
-func setupVolume(path string) string {
-	// Create volume
-	err := os.MkdirAll(path, os.ModePerm)
 	if err != nil {
-		msg := "Unable to create volume %s, because of %v"
-		log.Fatalf(msg, path, err)
+		return nil, fmt.Errorf("unable to create storage: %w", err)
 	}
-	return path
+	return s, nil
+}
+
+// NewStagingKey returns unique key for uploaded file
+func NewStagingKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return stagingPrefix + hex.EncodeToString(b)
+}
+
+// ListStagedFiles returns files of s not yet moved to blob store
+func ListStagedFiles(ctx context.Context, s Storage) ([]FileInfo, error) {
+	return s.List(ctx, stagingPrefix)
 }
 
-func GetPath() string {
-	return path
+// GetBlobKey returns key of blob with given hash,
+// blobs are spread over prefixes by first two hash characters
+func GetBlobKey(hash string) string {
+	return blobsPrefix + hash[:2] + "/" + hash
 }